@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 
 	log "github.com/Sirupsen/logrus"
@@ -17,14 +18,15 @@ type Command struct {
 	Help    string
 
 	Flag     flag.FlagSet
-	Function func(*gapi.Client, *Command, []string) error
+	Function func(context.Context, *gapi.Client, *Command, []string) error
 }
 
-// Run sets up the environment then executes the command.
-func (c *Command) Run(client *gapi.Client, args []string) {
+// Run sets up the environment then executes the command. Requests made by the
+// command are cancelled when ctx is done.
+func (c *Command) Run(ctx context.Context, client *gapi.Client, args []string) {
 	c.Flag.Parse(args)
 
-	if err := c.Function(client, c, c.Flag.Args()); err != nil {
+	if err := c.Function(ctx, client, c, c.Flag.Args()); err != nil {
 		log.Fatal(err)
 	}
 }
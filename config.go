@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+	"gopkg.in/yaml.v3"
+)
+
+var configCmd = &Command{
+	Name:    "config",
+	Usage:   "[OPTIONS] use-context|get-contexts|set-context [NAME]",
+	Summary: "Manage Grafana contexts in the configuration file.",
+	Help: `The config command manages the configuration file, which holds named
+contexts with the settings for each Grafana instance: URL, credentials (key),
+headers, organization ID and local repository path.
+
+The context to use is selected by -context, or is the current context set in
+the file. Its settings are used for any options not given on the command line
+or in the environment.
+
+	get-contexts
+		List contexts, marking the current context.
+	use-context NAME
+		Set the current context.
+	set-context [OPTIONS] NAME
+		Create or update the context NAME, setting the given options.`,
+}
+
+var (
+	ctxURL = configCmd.Flag.String("url", "",
+		"Grafana base URL of context")
+	ctxKey = configCmd.Flag.String("key", "",
+		"Grafana API key or user:pass of context")
+	ctxHeaders = configCmd.Flag.String("headers", "",
+		"extra headers of context")
+	ctxOrg = configCmd.Flag.Int64("org", 0,
+		"organization ID of context")
+	ctxPath = configCmd.Flag.String("path", "",
+		"local repository path of context")
+)
+
+// Config is the configuration file.
+type Config struct {
+	CurrentContext string                    `yaml:"current-context,omitempty"`
+	Contexts       map[string]*ConfigContext `yaml:"contexts"`
+}
+
+// ConfigContext holds the settings for a Grafana instance.
+type ConfigContext struct {
+	URL     string `yaml:"url,omitempty"`
+	Key     string `yaml:"key,omitempty"`
+	Headers string `yaml:"headers,omitempty"`
+	Org     int64  `yaml:"org,omitempty"`
+	Path    string `yaml:"path,omitempty"`
+}
+
+// defaultConfigFile returns the default location of the configuration file,
+// e.g. ~/.config/grafanactl/config.yaml
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "grafanactl", "config.yaml")
+}
+
+// loadConfig reads the configuration from filename. A missing file is an
+// empty configuration.
+func loadConfig(filename string) (*Config, error) {
+	cfg := &Config{Contexts: make(map[string]*ConfigContext)}
+	dat, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) || filename == "" {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(dat, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	if cfg.Contexts == nil {
+		cfg.Contexts = make(map[string]*ConfigContext)
+	}
+	// a context with no settings unmarshals to nil
+	for name, c := range cfg.Contexts {
+		if c == nil {
+			cfg.Contexts[name] = &ConfigContext{}
+		}
+	}
+	return cfg, nil
+}
+
+// saveConfig writes cfg to filename. The file is only readable by the user,
+// since it may hold credentials.
+func saveConfig(cfg *Config, filename string) error {
+	dat, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, dat, 0600)
+}
+
+// applyContext sets any global options that are still at their defaults from
+// the selected context in the configuration file.
+func applyContext() error {
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	name := *contextName
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	if name == "" {
+		return nil
+	}
+	c, ok := cfg.Contexts[name]
+	if !ok {
+		return fmt.Errorf("context %s not found in %s", name, *configFile)
+	}
+	log.WithField("context", name).Debug("using context")
+	if *url == DEFAULT_URL && c.URL != "" {
+		flag.Set("url", c.URL)
+	}
+	if *key == "" && c.Key != "" {
+		flag.Set("key", c.Key)
+	}
+	if *headers == "" && c.Headers != "" {
+		flag.Set("headers", c.Headers)
+	}
+	if *org == 0 && c.Org != 0 {
+		flag.Set("org", strconv.FormatInt(c.Org, 10))
+	}
+	if *path == DEFAULT_PATH && c.Path != "" {
+		flag.Set("path", c.Path)
+	}
+	return nil
+}
+
+func configFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing config subcommand: use-context, get-contexts, set-context")
+	}
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		log.WithField("file", *configFile).Error(err)
+		return fmt.Errorf("error loading configuration file")
+	}
+	switch args[0] {
+	case "get-contexts":
+		names := make([]string, 0, len(cfg.Contexts))
+		for n := range cfg.Contexts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		fmt.Printf("CURRENT NAME                 URL\n")
+		for _, n := range names {
+			current := ""
+			if n == cfg.CurrentContext {
+				current = "*"
+			}
+			fmt.Printf("%-7s %-20s %s\n", current, n, cfg.Contexts[n].URL)
+		}
+		return nil
+	case "use-context":
+		if len(args) != 2 {
+			return fmt.Errorf("config use-context requires a NAME")
+		}
+		if _, ok := cfg.Contexts[args[1]]; !ok {
+			return fmt.Errorf("context %s not found", args[1])
+		}
+		cfg.CurrentContext = args[1]
+	case "set-context":
+		if len(args) < 2 {
+			return fmt.Errorf("config set-context requires a NAME")
+		}
+		// allow options after the name too
+		if err := cmd.Flag.Parse(args[2:]); err != nil {
+			return err
+		}
+		c, ok := cfg.Contexts[args[1]]
+		if !ok {
+			c = &ConfigContext{}
+			cfg.Contexts[args[1]] = c
+		}
+		cmd.Flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "url":
+				c.URL = *ctxURL
+			case "key":
+				c.Key = *ctxKey
+			case "headers":
+				c.Headers = *ctxHeaders
+			case "org":
+				c.Org = *ctxOrg
+			case "path":
+				c.Path = *ctxPath
+			}
+		})
+	default:
+		return fmt.Errorf("unknown config subcommand %s", args[0])
+	}
+	if err := saveConfig(cfg, *configFile); err != nil {
+		log.WithField("file", *configFile).Error(err)
+		return fmt.Errorf("error saving configuration file")
+	}
+	return nil
+}
+
+func init() {
+	configCmd.Function = configFunc
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+var copyCmd = &Command{
+	Name:    "copy",
+	Usage:   "[OPTIONS] [DASHBOARD...]",
+	Summary: "Copy dashboards from one Grafana to another.",
+	Help: `The copy command copies dashboards directly from one Grafana instance
+to another, without saving them to file.
+If no dashboards are specified, copy all available dashboards. Specify
+dashboards as for get, or filter them with -query, -tag, -starred, -folder
+and -type. Dashboards are copied by -parallel concurrent workers; failures are
+reported in a summary after all dashboards have been attempted.
+
+The source (-from) and destination (-to) are each either the name of a context
+in the configuration file or a URL, with credentials given by -from-key and
+-to-key. If -from is not given, the source is the Grafana selected by the
+global options.
+
+Dashboards are saved in the destination in a folder with the same title as in
+the source, or in the folder given by -dest-folder; missing folders are
+created. Datasource references can be renamed with -datasource-map, a JSON
+file mapping source datasource names or UIDs to destination names or UIDs;
+references that are not found in the destination are reported.`,
+}
+
+var (
+	copyFrom = copyCmd.Flag.String("from", "",
+		"source context or URL")
+	copyFromKey = copyCmd.Flag.String("from-key", "",
+		"source API key or user:pass, if -from is a URL")
+	copyTo = copyCmd.Flag.String("to", "",
+		"destination context or URL")
+	copyToKey = copyCmd.Flag.String("to-key", "",
+		"destination API key or user:pass, if -to is a URL")
+	copyFolder = copyCmd.Flag.String("dest-folder", "",
+		"folder to save dashboards in, instead of the source folder")
+	copyDataSourceMap = copyCmd.Flag.String("datasource-map", "",
+		"JSON file mapping source to destination datasource names or UIDs")
+	copyOverwrite = copyCmd.Flag.Bool("overwrite", false,
+		"overwrite existing dashboards")
+	copyParallel = copyCmd.Flag.Int("parallel", 1,
+		"number of dashboards to copy concurrently")
+	copySearch = addSearchFlags(&copyCmd.Flag)
+)
+
+func copyFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	if *copyTo == "" {
+		return fmt.Errorf("no destination given (-to)")
+	}
+	src := client
+	if *copyFrom != "" {
+		var err error
+		if src, err = clientFor(*copyFrom, *copyFromKey); err != nil {
+			log.WithField("from", *copyFrom).Error(err)
+			return fmt.Errorf("error setting up source client")
+		}
+	}
+	dst, err := clientFor(*copyTo, *copyToKey)
+	if err != nil {
+		log.WithField("to", *copyTo).Error(err)
+		return fmt.Errorf("error setting up destination client")
+	}
+	dsMap, err := readDataSourceMap(*copyDataSourceMap)
+	if err != nil {
+		log.WithField("file", *copyDataSourceMap).Error(err)
+		return fmt.Errorf("error reading datasource map")
+	}
+
+	dashboards, index, err := selectDashboards(ctx, src, args, copySearch)
+	if err != nil {
+		return err
+	}
+	known := knownDataSources(ctx, dst)
+	folderList, err := dst.FoldersCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error getting destination folder list")
+	}
+	folders := make(map[string]*gapi.Folder)
+	for i := range folderList {
+		folders[folderList[i].Title] = &folderList[i]
+	}
+	// guards folders, so that each missing folder is created once
+	var foldersMu sync.Mutex
+
+	results := runParallel(*copyParallel, dashboards, func(d string) error {
+		ll := log.WithField("dashboard", d)
+		dash, err := index.dashboard(ctx, src, d)
+		if err != nil {
+			ll.Error(err)
+			return fmt.Errorf("error getting dashboard: %s", err)
+		}
+		title := *copyFolder
+		if title == "" {
+			title = dash.Meta.FolderTitle
+		}
+		var folder *gapi.Folder
+		if title != "" && title != generalFolder {
+			foldersMu.Lock()
+			if folder = folders[title]; folder == nil {
+				if folder, err = dst.NewFolderCtx(ctx, title); err != nil {
+					foldersMu.Unlock()
+					ll.WithField("folder", title).Error(err)
+					return fmt.Errorf("error creating folder: %s", err)
+				}
+				folders[title] = folder
+				ll.WithField("folder", title).Info("folder created")
+			}
+			foldersMu.Unlock()
+		}
+		remapAndCheck(dash.Model, dsMap, known, ll)
+		// the id is specific to the source instance
+		dash.Model["id"] = nil
+		resp, err := dst.SaveDashboardInFolderCtx(ctx, dash.Model, folder, *copyOverwrite)
+		if err != nil {
+			ll.Error(err)
+			return fmt.Errorf("error saving dashboard: %s", err)
+		}
+		ll.WithFields(log.Fields{
+			"folder":  title,
+			"status":  resp.Status,
+			"version": resp.Version,
+		}).Info("dashboard copied")
+		return nil
+	})
+	return summarize("copy", results)
+}
+
+// clientFor returns a client for spec, which is either a URL or the name of a
+// context in the configuration file. If key is not empty it overrides the
+// credentials of the context.
+func clientFor(spec, key string) (*gapi.Client, error) {
+	if strings.Contains(spec, "://") {
+		return newClient(spec, key, "", 0)
+	}
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := cfg.Contexts[spec]
+	if !ok {
+		return nil, fmt.Errorf("context %s not found in %s", spec, *configFile)
+	}
+	if key == "" {
+		key = c.Key
+	}
+	return newClient(c.URL, key, c.Headers, c.Org)
+}
+
+func init() {
+	copyCmd.Function = copyFunc
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+var datasourceCmd = &Command{
+	Name:    "datasource",
+	Usage:   "[OPTIONS] list|get|push|delete [NAME...]",
+	Summary: "Manage datasources.",
+	Help: `The datasource command manages Grafana datasources.
+
+	list
+		List datasources.
+	get [NAME...]
+		Retrieve datasources and save to file in the 'datasources'
+		sub-directory of the specified path. If no datasources are specified,
+		retrieve all datasources. Passwords and secure settings
+		(secureJsonData) are replaced with placeholders, e.g.
+		${GRAFANA_DS_PROD_PG_PASSWORD} or ${GRAFANA_DS_PROD_PG_TLSCACERT}.
+	push [NAME...]
+		Read datasources from file and push to Grafana. Datasources that
+		already exist (by name) are updated, replacing their settings
+		(jsonData), others are created. If no
+		datasources are specified, push all datasources in the specified path.
+		Secret placeholders are resolved from environment variables or the
+		-secrets file; it is an error if any remain unresolved.
+	delete NAME...
+		Delete datasources from Grafana.`,
+}
+
+var (
+	dsFormat = datasourceCmd.Flag.String("format", "short",
+		"list format: short, long, json")
+	secretsFile = datasourceCmd.Flag.String("secrets", "",
+		"file of KEY=VALUE secrets used to resolve password placeholders")
+)
+
+func datasourceFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing datasource subcommand: list, get, push, delete")
+	}
+	dirname := filepath.Join(*path, dataSourceDir)
+	switch args[0] {
+	case "list":
+		return listDataSources(ctx, client)
+	case "get":
+		return getDataSources(ctx, client, dirname, args[1:])
+	case "push":
+		return pushDataSources(ctx, client, dirname, args[1:])
+	case "delete":
+		return deleteDataSources(ctx, client, args[1:])
+	}
+	return fmt.Errorf("unknown datasource subcommand %s", args[0])
+}
+
+func listDataSources(ctx context.Context, client *gapi.Client) error {
+	dsl, err := client.DataSourcesCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error getting datasource list")
+	}
+	switch *dsFormat {
+	case "short":
+		for _, ds := range dsl {
+			fmt.Println(ds.Name)
+		}
+	case "long":
+		fmt.Printf("ID     NAME                           TYPE                 URL\n")
+		for _, ds := range dsl {
+			fmt.Printf("%-6d %-30s %-20s %s\n", ds.Id, ds.Name, ds.Type, ds.URL)
+		}
+	case "json":
+		// older Grafana versions report passwords in the list
+		for _, ds := range dsl {
+			redactDataSource(ds)
+		}
+		b, err := json.MarshalIndent(dsl, "", "\t")
+		if err != nil {
+			log.Error(err)
+			return fmt.Errorf("error marshalling datasource list to JSON")
+		}
+		fmt.Printf("%s", b)
+	default:
+		return fmt.Errorf("unknown format %s", *dsFormat)
+	}
+	return nil
+}
+
+func getDataSources(ctx context.Context, client *gapi.Client, dir string, names []string) error {
+	dsl, err := client.DataSourcesCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error getting datasource list")
+	}
+	byName := dataSourcesByName(dsl)
+	if len(names) == 0 {
+		for name := range byName {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.WithField("path", dir).Error(err)
+		return fmt.Errorf("error creating datasource directory")
+	}
+	for _, name := range names {
+		summary, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("datasource %s not found", name)
+		}
+		// the list may not include all fields, so get the full datasource
+		ds, err := client.DataSourceCtx(ctx, summary.Id)
+		if err != nil {
+			log.WithField("datasource", name).Error(err)
+			return fmt.Errorf("error getting datasource")
+		}
+		redactDataSource(ds)
+		filename := dataSourceFilename(dir, name)
+		log.WithFields(log.Fields{
+			"datasource": name,
+			"file":       filename,
+		}).Info("saving datasource")
+		if err := writeDataSource(ds, filename); err != nil {
+			log.WithField("datasource", name).Error(err)
+			return fmt.Errorf("error saving datasource to file")
+		}
+	}
+	return nil
+}
+
+func pushDataSources(ctx context.Context, client *gapi.Client, dir string, names []string) error {
+	var files []string
+	if len(names) == 0 {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			log.WithField("path", dir).Error(err)
+			return fmt.Errorf("error getting list of datasources")
+		}
+		files = matches
+	} else {
+		for _, name := range names {
+			files = append(files, dataSourceFilename(dir, name))
+		}
+	}
+	dsl, err := client.DataSourcesCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error getting datasource list")
+	}
+	byName := dataSourcesByName(dsl)
+	secrets, err := readSecretsFile(*secretsFile)
+	if err != nil {
+		log.WithField("file", *secretsFile).Error(err)
+		return fmt.Errorf("error reading secrets file")
+	}
+	// load and resolve all datasources before pushing any
+	datasources := make([]*gapi.DataSource, len(files))
+	for i, filename := range files {
+		ds, err := readDataSource(filename)
+		if err != nil {
+			log.WithField("file", filename).Error(err)
+			return fmt.Errorf("error loading datasource from file")
+		}
+		if err := resolveDataSource(ds, secrets); err != nil {
+			return err
+		}
+		datasources[i] = ds
+	}
+	for i, ds := range datasources {
+		filename := files[i]
+		ll := log.WithFields(log.Fields{
+			"datasource": ds.Name,
+			"file":       filename,
+		})
+		if existing, ok := byName[ds.Name]; ok {
+			ds.Id = existing.Id
+			// files saved before jsonData was carried through would clear
+			// the settings, so keep the existing ones
+			if ds.JSONData == nil && existing.JSONData != nil {
+				ll.Warning("no jsonData in file, keeping existing settings")
+				ds.JSONData = existing.JSONData
+			}
+			if err := client.UpdateDataSourceCtx(ctx, ds); err != nil {
+				ll.Error(err)
+				return fmt.Errorf("error updating datasource")
+			}
+			ll.WithField("id", ds.Id).Info("datasource updated")
+		} else {
+			ds.Id = 0
+			id, err := client.NewDataSourceCtx(ctx, ds)
+			if err != nil {
+				ll.Error(err)
+				return fmt.Errorf("error creating datasource")
+			}
+			ll.WithField("id", id).Info("datasource created")
+		}
+	}
+	return nil
+}
+
+func deleteDataSources(ctx context.Context, client *gapi.Client, names []string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("no datasources specified")
+	}
+	dsl, err := client.DataSourcesCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error getting datasource list")
+	}
+	byName := dataSourcesByName(dsl)
+	for _, name := range names {
+		ds, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("datasource %s not found", name)
+		}
+		if err := client.DeleteDataSourceCtx(ctx, ds.Id); err != nil {
+			log.WithField("datasource", name).Error(err)
+			return fmt.Errorf("error deleting datasource")
+		}
+		log.WithField("datasource", name).Info("datasource deleted")
+	}
+	return nil
+}
+
+func dataSourcesByName(dsl []*gapi.DataSource) map[string]*gapi.DataSource {
+	m := make(map[string]*gapi.DataSource, len(dsl))
+	for _, ds := range dsl {
+		m[ds.Name] = ds
+	}
+	return m
+}
+
+// dataSourceFilename returns the file in dir that holds the datasource with
+// name. Path separators in the name are replaced.
+func dataSourceFilename(dir, name string) string {
+	return filepath.Join(dir, safeFilename(name)+".json")
+}
+
+func writeDataSource(ds *gapi.DataSource, filename string) error {
+	d, err := json.MarshalIndent(ds, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, d, 0644)
+}
+
+// readDataSource reads a JSON datasource from file and unmarshals it
+func readDataSource(filename string) (*gapi.DataSource, error) {
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	ds := &gapi.DataSource{}
+	if err = json.Unmarshal(dat, ds); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+func init() {
+	datasourceCmd.Function = datasourceFunc
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+var diffCmd = &Command{
+	Name:    "diff",
+	Usage:   "[OPTIONS] [DASHBOARD...]",
+	Summary: "Compare local dashboards against Grafana.",
+	Help: `The diff command compares dashboards in the local repository with the
+dashboards in Grafana and prints the differences, i.e. what push would change.
+If no dashboards are specified, compare all dashboards in the specified path.
+Specify dashboards by file name, i.e. UID or slug (e.g. 'db/foo' or just
+'foo'). Local dashboards are matched to those in Grafana by the uid in the
+file, or by file name as UID or slug, so repositories saved before Grafana
+supported UIDs still match.
+
+Jsonnet dashboards are evaluated as for push, with -jpath and -ext-str, and
+with -template dashboard files are rendered as templates as for push.
+
+Volatile fields that Grafana manages (id, version, iteration) are ignored.
+Exits with a non-zero status if any dashboard differs.`,
+}
+
+var (
+	diffTemplate = diffCmd.Flag.Bool("template", false,
+		"render dashboard files as templates (see 'help render')")
+	diffVars = addTemplateFlags(&diffCmd.Flag)
+)
+
+// volatileFields are dashboard fields managed by Grafana that change on every
+// save and are not meaningful when comparing dashboards.
+var volatileFields = []string{"id", "version", "iteration"}
+
+// difference is a single difference between two dashboard models. Path
+// locates the value in the model, e.g. "panels[3].targets[0].expr". A missing
+// value is indicated by the corresponding has flag being false.
+type difference struct {
+	Path      string
+	Remote    interface{}
+	Local     interface{}
+	HasRemote bool
+	HasLocal  bool
+}
+
+func (d difference) String() string {
+	switch {
+	case !d.HasRemote:
+		return fmt.Sprintf("+ %s: %s", d.Path, compactJSON(d.Local))
+	case !d.HasLocal:
+		return fmt.Sprintf("- %s: %s", d.Path, compactJSON(d.Remote))
+	default:
+		return fmt.Sprintf("~ %s: %s => %s", d.Path, compactJSON(d.Remote), compactJSON(d.Local))
+	}
+}
+
+func diffFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	dirname := filepath.Join(*path, "db")
+	dashboards, err := localDashboards(dirname, args, "")
+	if err != nil {
+		log.WithField("path", dirname).Error(err)
+		return fmt.Errorf("error getting list of dashboards")
+	}
+	index, err := indexDashboards(ctx, client)
+	if err != nil {
+		return err
+	}
+	drift := 0
+	for _, d := range dashboards {
+		filename, err := localFilename(dirname, d, "")
+		if err != nil {
+			return err
+		}
+		local, err := readDashboard(filename, templateIf(*diffTemplate, diffVars))
+		if err != nil {
+			log.WithField("file", filename).Error(err)
+			return fmt.Errorf("error loading dashboard from file")
+		}
+		name, ok := index.resolve(d, local)
+		if !ok {
+			fmt.Printf("%s: not found in Grafana\n", d)
+			drift++
+			continue
+		}
+		remote, err := index.scheme.dashboard(ctx, client, name)
+		if err != nil {
+			if !gapi.IsNotFound(err) {
+				log.WithField("dashboard", d).Error(err)
+				return fmt.Errorf("error getting dashboard")
+			}
+			fmt.Printf("%s: not found in Grafana\n", d)
+			drift++
+			continue
+		}
+		diffs := diffDashboards(remote.Model, local)
+		if len(diffs) == 0 {
+			log.WithField("dashboard", d).Debug("no differences")
+			continue
+		}
+		drift++
+		fmt.Printf("%s: %d difference(s) (remote => local)\n", d, len(diffs))
+		for _, diff := range diffs {
+			fmt.Printf("\t%s\n", diff)
+		}
+	}
+	if drift > 0 {
+		return fmt.Errorf("%d of %d dashboard(s) differ", drift, len(dashboards))
+	}
+	return nil
+}
+
+// diffDashboards compares the remote and local dashboard models, ignoring
+// volatile fields, and returns the differences.
+func diffDashboards(remote, local map[string]interface{}) []difference {
+	return diffValues("", normalizeModel(remote), normalizeModel(local))
+}
+
+// normalizeModel returns a shallow copy of model without volatile fields.
+func normalizeModel(model map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(model))
+	for k, v := range model {
+		m[k] = v
+	}
+	for _, f := range volatileFields {
+		delete(m, f)
+	}
+	return m
+}
+
+// diffValues recursively compares two values decoded from JSON and returns
+// the differences, with paths relative to path.
+func diffValues(path string, remote, local interface{}) []difference {
+	switch r := remote.(type) {
+	case map[string]interface{}:
+		if l, ok := local.(map[string]interface{}); ok {
+			return diffMaps(path, r, l)
+		}
+	case []interface{}:
+		if l, ok := local.([]interface{}); ok {
+			return diffSlices(path, r, l)
+		}
+	default:
+		switch local.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			if remote == local {
+				return nil
+			}
+		}
+	}
+	return []difference{{
+		Path:      path,
+		Remote:    remote,
+		Local:     local,
+		HasRemote: true,
+		HasLocal:  true,
+	}}
+}
+
+func diffMaps(path string, remote, local map[string]interface{}) []difference {
+	keys := make([]string, 0, len(remote)+len(local))
+	for k := range remote {
+		keys = append(keys, k)
+	}
+	for k := range local {
+		if _, ok := remote[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var diffs []difference
+	for _, k := range keys {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+		r, hasRemote := remote[k]
+		l, hasLocal := local[k]
+		if hasRemote && hasLocal {
+			diffs = append(diffs, diffValues(p, r, l)...)
+		} else {
+			diffs = append(diffs, difference{
+				Path:      p,
+				Remote:    r,
+				Local:     l,
+				HasRemote: hasRemote,
+				HasLocal:  hasLocal,
+			})
+		}
+	}
+	return diffs
+}
+
+func diffSlices(path string, remote, local []interface{}) []difference {
+	var diffs []difference
+	for i := 0; i < len(remote) || i < len(local); i++ {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(local):
+			diffs = append(diffs, difference{Path: p, Remote: remote[i], HasRemote: true})
+		case i >= len(remote):
+			diffs = append(diffs, difference{Path: p, Local: local[i], HasLocal: true})
+		default:
+			diffs = append(diffs, diffValues(p, remote[i], local[i])...)
+		}
+	}
+	return diffs
+}
+
+// compactJSON returns v as single-line JSON, for display.
+func compactJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func init() {
+	diffCmd.Function = diffFunc
+}
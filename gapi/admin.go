@@ -2,8 +2,8 @@ package gapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 
@@ -11,12 +11,17 @@ import (
 )
 
 func (c *Client) CreateUserForm(settings dtos.AdminCreateUserForm) error {
+	return c.CreateUserFormCtx(context.Background(), settings)
+}
+
+// CreateUserFormCtx is like CreateUserForm, using ctx for the request.
+func (c *Client) CreateUserFormCtx(ctx context.Context, settings dtos.AdminCreateUserForm) error {
 	data, err := json.Marshal(settings)
-	req, err := c.newRequest("POST", "/api/admin/users", bytes.NewBuffer(data))
+	req, err := c.newRequest(ctx, "POST", "/api/admin/users", bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -25,22 +30,27 @@ func (c *Client) CreateUserForm(settings dtos.AdminCreateUserForm) error {
 		return err
 	}
 	if resp.StatusCode != 200 {
-		return errors.New(resp.Status)
+		return apiError(resp, data)
 	}
 	return err
 }
 
 func (c *Client) DeleteUser(id int64) error {
-	req, err := c.newRequest("DELETE", fmt.Sprintf("/api/admin/users/%d", id), nil)
+	return c.DeleteUserCtx(context.Background(), id)
+}
+
+// DeleteUserCtx is like DeleteUser, using ctx for the request.
+func (c *Client) DeleteUserCtx(ctx context.Context, id int64) error {
+	req, err := c.newRequest(ctx, "DELETE", fmt.Sprintf("/api/admin/users/%d", id), nil)
 	if err != nil {
 		return err
 	}
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode != 200 {
-		return errors.New(resp.Status)
+		return newApiError(resp)
 	}
 	return err
 }
@@ -2,6 +2,8 @@ package gapi
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -9,28 +11,106 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
 )
 
+// ApiError is returned when Grafana responds to a request with an error.
 type ApiError struct {
 	ResponseCode   int
 	ResponseStatus string
-	Message        string
+	// Message is Grafana's explanation of the error, if it gave one.
+	Message string
+	// Method and Path identify the failed request.
+	Method string
+	Path   string
 }
 
 func (e ApiError) Error() string {
-	if e.Message != "" {
-		return e.Message
+	msg := e.ResponseStatus
+	if e.Message != "" && e.Message != e.ResponseStatus {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
 	}
-	return e.ResponseStatus
+	if e.Method != "" {
+		return fmt.Sprintf("%s %s: %s", e.Method, e.Path, msg)
+	}
+	return msg
+}
+
+// newApiError reads the body of the failed response resp and returns the
+// corresponding ApiError.
+func newApiError(resp *http.Response) ApiError {
+	body, _ := ioutil.ReadAll(resp.Body)
+	return apiError(resp, body)
+}
+
+// apiError returns the ApiError for the failed response resp, with the
+// message from the JSON error body if there is one.
+func apiError(resp *http.Response, body []byte) ApiError {
+	e := ApiError{
+		ResponseCode:   resp.StatusCode,
+		ResponseStatus: resp.Status,
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.Path = resp.Request.URL.Path
+	}
+	result := struct {
+		Message string `json:"message"`
+	}{}
+	if json.Unmarshal(body, &result) == nil {
+		e.Message = result.Message
+	}
+	return e
+}
+
+// hasStatus returns true if err is an ApiError with one of the status codes.
+func hasStatus(err error, codes ...int) bool {
+	var e ApiError
+	if !errors.As(err, &e) {
+		return false
+	}
+	for _, c := range codes {
+		if e.ResponseCode == c {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound returns true if err is an ApiError because the requested
+// resource does not exist.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict returns true if err is an ApiError because the request conflicts
+// with the current state of the resource, e.g. saving a dashboard that has
+// been changed since it was retrieved, or with a name that is already taken.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict, http.StatusPreconditionFailed)
+}
+
+// IsUnauthorized returns true if err is an ApiError because the request was
+// not authenticated.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden returns true if err is an ApiError because the authenticated
+// user is not allowed to make the request.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
 }
 
 type Client struct {
 	key     string
 	headers map[string]string
 	baseURL url.URL
+	// Retry is the policy for retrying idempotent requests
+	Retry RetryPolicy
 	*http.Client
 }
 
@@ -38,7 +118,8 @@ type Client struct {
 //auth can be in user:pass format, or it can be an api key
 //headers should be a comma-separated list of extra headers to send with each
 //request, e.g. "X-User:foo,X-Grafana-Org-Id:1"
-func New(auth, headers, baseURL string) (*Client, error) {
+//opts configure the client further, e.g. WithCACert
+func New(auth, headers, baseURL string, opts ...Option) (*Client, error) {
 	u, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, err
@@ -58,18 +139,37 @@ func New(auth, headers, baseURL string) (*Client, error) {
 			hdr[kv[0]] = kv[1]
 		}
 	}
-	return &Client{
+	c := &Client{
 		key:     key,
 		headers: hdr,
 		baseURL: *u,
 		Client:  &http.Client{},
-	}, nil
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// WithOrg returns a copy of the client that sends requests in the context of
+// the organization with id, by setting the X-Grafana-Org-Id header.
+func (c *Client) WithOrg(id int64) *Client {
+	hdr := make(map[string]string, len(c.headers)+1)
+	for k, v := range c.headers {
+		hdr[k] = v
+	}
+	hdr["X-Grafana-Org-Id"] = strconv.FormatInt(id, 10)
+	oc := *c
+	oc.headers = hdr
+	return &oc
 }
 
-func (c *Client) newRequest(method, uri string, body io.Reader) (*http.Request, error) {
+func (c *Client) newRequest(ctx context.Context, method, uri string, body io.Reader) (*http.Request, error) {
 	url := c.baseURL
 	url.Path = path.Join(url.Path, uri)
-	req, err := http.NewRequest(method, url.String(), body)
+	req, err := http.NewRequestWithContext(ctx, method, url.String(), body)
 	if err != nil {
 		return req, err
 	}
@@ -94,12 +194,12 @@ func (c *Client) newRequest(method, uri string, body io.Reader) (*http.Request,
 }
 
 func (c *Client) DoRead(req *http.Request) ([]byte, error) {
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != 200 {
-		return nil, errors.New(resp.Status)
+		return nil, newApiError(resp)
 	}
 
 	data, err := ioutil.ReadAll(resp.Body)
@@ -2,18 +2,25 @@ package gapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"path"
+	"strconv"
 
 	log "github.com/Sirupsen/logrus"
 )
 
 type DashboardMeta struct {
-	IsStarred bool   `json:"isStarred"`
-	Slug      string `json:"slug"`
+	IsStarred   bool   `json:"isStarred"`
+	Slug        string `json:"slug"`
+	UID         string `json:"uid"`
+	URL         string `json:"url"`
+	FolderId    int64  `json:"folderId"`
+	FolderUID   string `json:"folderUid"`
+	FolderTitle string `json:"folderTitle"`
 }
 
 type DashboardSaveResponse struct {
@@ -37,13 +44,72 @@ type DashboardEntry struct {
 	Type      string   `json:"type"`
 	Tags      []string `json:"tags"`
 	IsStarred bool     `json:"isStarred"`
+	UID       string   `json:"uid"`
+	URL       string   `json:"url"`
+
+	FolderId    int64  `json:"folderId"`
+	FolderUID   string `json:"folderUid"`
+	FolderTitle string `json:"folderTitle"`
+}
+
+// SearchOptions filters the dashboards returned by SearchDashboards. Zero
+// values are not used to filter.
+type SearchOptions struct {
+	// Query matches dashboard titles.
+	Query string
+	// Tags must all be set on the dashboard.
+	Tags []string
+	// Starred matches only dashboards starred by the user.
+	Starred bool
+	// FolderIds matches dashboards in any of the folders; the General
+	// folder is 0.
+	FolderIds []int64
+	// Type is "dash-db" or "dash-folder".
+	Type string
+}
+
+// values returns the search options as /api/search query parameters.
+func (o SearchOptions) values() url.Values {
+	v := url.Values{}
+	if o.Query != "" {
+		v.Set("query", o.Query)
+	}
+	for _, t := range o.Tags {
+		v.Add("tag", t)
+	}
+	if o.Starred {
+		v.Set("starred", "true")
+	}
+	for _, id := range o.FolderIds {
+		v.Add("folderIds", strconv.FormatInt(id, 10))
+	}
+	if o.Type != "" {
+		v.Set("type", o.Type)
+	}
+	return v
 }
 
 func (c *Client) ListDashboards() (*DashboardList, error) {
-	req, err := c.newRequest("GET", "/api/search", nil)
+	return c.ListDashboardsCtx(context.Background())
+}
+
+// ListDashboardsCtx is like ListDashboards, using ctx for the request.
+func (c *Client) ListDashboardsCtx(ctx context.Context) (*DashboardList, error) {
+	return c.SearchDashboardsCtx(ctx, SearchOptions{})
+}
+
+// SearchDashboards lists the dashboards and folders matching opts.
+func (c *Client) SearchDashboards(opts SearchOptions) (*DashboardList, error) {
+	return c.SearchDashboardsCtx(context.Background(), opts)
+}
+
+// SearchDashboardsCtx is like SearchDashboards, using ctx for the request.
+func (c *Client) SearchDashboardsCtx(ctx context.Context, opts SearchOptions) (*DashboardList, error) {
+	req, err := c.newRequest(ctx, "GET", "/api/search", nil)
 	if err != nil {
 		return nil, err
 	}
+	req.URL.RawQuery = opts.values().Encode()
 	d, err := c.DoRead(req)
 	if err != nil {
 		return nil, err
@@ -57,20 +123,50 @@ func (c *Client) ListDashboards() (*DashboardList, error) {
 }
 
 func (c *Client) SaveDashboard(model map[string]interface{}, overwrite bool) (*DashboardSaveResponse, error) {
+	return c.SaveDashboardCtx(context.Background(), model, overwrite)
+}
+
+// SaveDashboardCtx is like SaveDashboard, using ctx for the request.
+func (c *Client) SaveDashboardCtx(ctx context.Context, model map[string]interface{}, overwrite bool) (*DashboardSaveResponse, error) {
+	wrapper := map[string]interface{}{
+		"dashboard": model,
+		"overwrite": overwrite,
+	}
+	return c.saveDashboard(ctx, wrapper)
+}
+
+// SaveDashboardInFolder saves the dashboard model in folder, moving it if it
+// already exists in another folder. If folder is nil the dashboard is saved
+// in the General folder.
+func (c *Client) SaveDashboardInFolder(model map[string]interface{}, folder *Folder, overwrite bool) (*DashboardSaveResponse, error) {
+	return c.SaveDashboardInFolderCtx(context.Background(), model, folder, overwrite)
+}
+
+// SaveDashboardInFolderCtx is like SaveDashboardInFolder, using ctx for the request.
+func (c *Client) SaveDashboardInFolderCtx(ctx context.Context, model map[string]interface{}, folder *Folder, overwrite bool) (*DashboardSaveResponse, error) {
 	wrapper := map[string]interface{}{
 		"dashboard": model,
 		"overwrite": overwrite,
+		"folderId":  0,
+	}
+	if folder != nil {
+		wrapper["folderId"] = folder.Id
+		wrapper["folderUid"] = folder.UID
 	}
+	return c.saveDashboard(ctx, wrapper)
+}
+
+func (c *Client) saveDashboard(ctx context.Context, wrapper map[string]interface{}) (*DashboardSaveResponse, error) {
 	data, err := json.Marshal(wrapper)
 	if err != nil {
 		return nil, err
 	}
-	req, err := c.newRequest("POST", "/api/dashboards/db", bytes.NewBuffer(data))
+	req, err := c.newRequest(ctx, "POST", "/api/dashboards/db", bytes.NewBuffer(data))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -79,23 +175,13 @@ func (c *Client) SaveDashboard(model map[string]interface{}, overwrite bool) (*D
 	if err != nil {
 		return nil, err
 	}
-
-	result := &DashboardSaveResponse{}
-	if err = json.Unmarshal(data, &result); err != nil {
-		return result, err
-	}
-
-	switch resp.StatusCode {
-	case 200:
-		return result, nil
-	case 400, 412:
-		log.Error(resp.Status)
-		return result, ApiError{resp.StatusCode, resp.Status, result.Message}
-	default:
+	if resp.StatusCode != 200 {
 		log.Error(resp.Status)
-		return result, errors.New(resp.Status)
+		return nil, apiError(resp, data)
 	}
 
+	result := &DashboardSaveResponse{}
+	err = json.Unmarshal(data, &result)
 	return result, err
 }
 
@@ -103,22 +189,45 @@ func (c *Client) SaveDashboard(model map[string]interface{}, overwrite bool) (*D
 // and unmarshals it into a Dashboard structure.
 // If uri does not contain a path then "db/" is prepended.
 func (c *Client) Dashboard(uri string) (*Dashboard, error) {
+	return c.DashboardCtx(context.Background(), uri)
+}
+
+// DashboardCtx is like Dashboard, using ctx for the request.
+func (c *Client) DashboardCtx(ctx context.Context, uri string) (*Dashboard, error) {
 	if path.Dir(uri) == "." {
 		uri = path.Join("db", uri)
 	}
-	uri = path.Join("/api/dashboards", uri)
+	return c.dashboard(ctx, path.Join("/api/dashboards", uri))
+}
 
-	req, err := c.newRequest("GET", uri, nil)
+// DashboardByUID fetches the dashboard with the given uid,
+// and unmarshals it into a Dashboard structure.
+// Addressing dashboards by uid requires Grafana 5.0 or later.
+func (c *Client) DashboardByUID(uid string) (*Dashboard, error) {
+	return c.DashboardByUIDCtx(context.Background(), uid)
+}
+
+// DashboardByUIDCtx is like DashboardByUID, using ctx for the request.
+func (c *Client) DashboardByUIDCtx(ctx context.Context, uid string) (*Dashboard, error) {
+	result, err := c.dashboard(ctx, path.Join("/api/dashboards/uid", uid))
+	if err == nil && result.Meta.UID == "" {
+		result.Meta.UID = uid
+	}
+	return result, err
+}
+
+func (c *Client) dashboard(ctx context.Context, uri string) (*Dashboard, error) {
+	req, err := c.newRequest(ctx, "GET", uri, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != 200 {
-		return nil, errors.New(resp.Status)
+		return nil, newApiError(resp)
 	}
 
 	data, err := ioutil.ReadAll(resp.Body)
@@ -132,18 +241,37 @@ func (c *Client) Dashboard(uri string) (*Dashboard, error) {
 }
 
 func (c *Client) DeleteDashboard(slug string) error {
-	path := fmt.Sprintf("/api/dashboards/db/%s", slug)
-	req, err := c.newRequest("DELETE", path, nil)
+	return c.DeleteDashboardCtx(context.Background(), slug)
+}
+
+// DeleteDashboardCtx is like DeleteDashboard, using ctx for the request.
+func (c *Client) DeleteDashboardCtx(ctx context.Context, slug string) error {
+	return c.deleteDashboard(ctx, fmt.Sprintf("/api/dashboards/db/%s", slug))
+}
+
+// DeleteDashboardByUID deletes the dashboard with the given uid.
+// Addressing dashboards by uid requires Grafana 5.0 or later.
+func (c *Client) DeleteDashboardByUID(uid string) error {
+	return c.DeleteDashboardByUIDCtx(context.Background(), uid)
+}
+
+// DeleteDashboardByUIDCtx is like DeleteDashboardByUID, using ctx for the request.
+func (c *Client) DeleteDashboardByUIDCtx(ctx context.Context, uid string) error {
+	return c.deleteDashboard(ctx, path.Join("/api/dashboards/uid", uid))
+}
+
+func (c *Client) deleteDashboard(ctx context.Context, path string) error {
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode != 200 {
-		return ApiError{resp.StatusCode, resp.Status, resp.Status}
+		return newApiError(resp)
 	}
 
 	return nil
@@ -2,14 +2,15 @@ package gapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 )
 
 type DataSource struct {
 	Id     int64  `json:"id,omitempty"`
+	UID    string `json:"uid,omitempty"`
 	Name   string `json:"name"`
 	Type   string `json:"type"`
 	URL    string `json:"url"`
@@ -25,24 +26,37 @@ type DataSource struct {
 	BasicAuth         bool   `json:"basicAuth"`
 	BasicAuthUser     string `json:"basicAuthUser,omitempty"`
 	BasicAuthPassword string `json:"basicAuthPassword,omitempty"`
+
+	// JSONData holds type-specific settings, e.g. the Prometheus httpMethod.
+	// It is replaced as a whole on update, so must be carried through.
+	JSONData map[string]interface{} `json:"jsonData,omitempty"`
+	// SecureJSONData holds type-specific secrets. Grafana never returns
+	// them, only which are set in SecureJSONFields.
+	SecureJSONData   map[string]string `json:"secureJsonData,omitempty"`
+	SecureJSONFields map[string]bool   `json:"secureJsonFields,omitempty"`
 }
 
 func (c *Client) NewDataSource(s *DataSource) (int64, error) {
+	return c.NewDataSourceCtx(context.Background(), s)
+}
+
+// NewDataSourceCtx is like NewDataSource, using ctx for the request.
+func (c *Client) NewDataSourceCtx(ctx context.Context, s *DataSource) (int64, error) {
 	data, err := json.Marshal(s)
 	if err != nil {
 		return 0, err
 	}
-	req, err := c.newRequest("POST", "/api/datasources", bytes.NewBuffer(data))
+	req, err := c.newRequest(ctx, "POST", "/api/datasources", bytes.NewBuffer(data))
 	if err != nil {
 		return 0, err
 	}
 
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return 0, err
 	}
 	if resp.StatusCode != 200 {
-		return 0, errors.New(resp.Status)
+		return 0, newApiError(resp)
 	}
 
 	data, err = ioutil.ReadAll(resp.Body)
@@ -58,40 +72,79 @@ func (c *Client) NewDataSource(s *DataSource) (int64, error) {
 }
 
 func (c *Client) UpdateDataSource(s *DataSource) error {
+	return c.UpdateDataSourceCtx(context.Background(), s)
+}
+
+// UpdateDataSourceCtx is like UpdateDataSource, using ctx for the request.
+func (c *Client) UpdateDataSourceCtx(ctx context.Context, s *DataSource) error {
 	path := fmt.Sprintf("/api/datasources/%d", s.Id)
 	data, err := json.Marshal(s)
 	if err != nil {
 		return err
 	}
-	req, err := c.newRequest("PUT", path, bytes.NewBuffer(data))
+	req, err := c.newRequest(ctx, "PUT", path, bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode != 200 {
-		return errors.New(resp.Status)
+		return newApiError(resp)
 	}
 
 	return nil
 }
 
+func (c *Client) DataSources() ([]*DataSource, error) {
+	return c.DataSourcesCtx(context.Background())
+}
+
+// DataSourcesCtx is like DataSources, using ctx for the request.
+func (c *Client) DataSourcesCtx(ctx context.Context) ([]*DataSource, error) {
+	req, err := c.newRequest(ctx, "GET", "/api/datasources", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, newApiError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*DataSource, 0)
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
 func (c *Client) DataSource(id int64) (*DataSource, error) {
+	return c.DataSourceCtx(context.Background(), id)
+}
+
+// DataSourceCtx is like DataSource, using ctx for the request.
+func (c *Client) DataSourceCtx(ctx context.Context, id int64) (*DataSource, error) {
 	path := fmt.Sprintf("/api/datasources/%d", id)
-	req, err := c.newRequest("GET", path, nil)
+	req, err := c.newRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != 200 {
-		return nil, errors.New(resp.Status)
+		return nil, newApiError(resp)
 	}
 
 	data, err := ioutil.ReadAll(resp.Body)
@@ -105,18 +158,23 @@ func (c *Client) DataSource(id int64) (*DataSource, error) {
 }
 
 func (c *Client) DeleteDataSource(id int64) error {
+	return c.DeleteDataSourceCtx(context.Background(), id)
+}
+
+// DeleteDataSourceCtx is like DeleteDataSource, using ctx for the request.
+func (c *Client) DeleteDataSourceCtx(ctx context.Context, id int64) error {
 	path := fmt.Sprintf("/api/datasources/%d", id)
-	req, err := c.newRequest("DELETE", path, nil)
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode != 200 {
-		return errors.New(resp.Status)
+		return newApiError(resp)
 	}
 
 	return nil
@@ -0,0 +1,102 @@
+package gapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path"
+)
+
+type Folder struct {
+	Id    int64  `json:"id"`
+	UID   string `json:"uid"`
+	Title string `json:"title"`
+	URL   string `json:"url,omitempty"`
+}
+
+func (c *Client) Folders() ([]Folder, error) {
+	return c.FoldersCtx(context.Background())
+}
+
+// FoldersCtx is like Folders, using ctx for the request.
+func (c *Client) FoldersCtx(ctx context.Context) ([]Folder, error) {
+	folders := make([]Folder, 0)
+	req, err := c.newRequest(ctx, "GET", "/api/folders", nil)
+	if err != nil {
+		return folders, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return folders, err
+	}
+	if resp.StatusCode != 200 {
+		return folders, newApiError(resp)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return folders, err
+	}
+	err = json.Unmarshal(data, &folders)
+	return folders, err
+}
+
+func (c *Client) Folder(uid string) (*Folder, error) {
+	return c.FolderCtx(context.Background(), uid)
+}
+
+// FolderCtx is like Folder, using ctx for the request.
+func (c *Client) FolderCtx(ctx context.Context, uid string) (*Folder, error) {
+	req, err := c.newRequest(ctx, "GET", path.Join("/api/folders", uid), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, newApiError(resp)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	result := &Folder{}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// NewFolder creates a new folder with title, and returns it.
+func (c *Client) NewFolder(title string) (*Folder, error) {
+	return c.NewFolderCtx(context.Background(), title)
+}
+
+// NewFolderCtx is like NewFolder, using ctx for the request.
+func (c *Client) NewFolderCtx(ctx context.Context, title string) (*Folder, error) {
+	settings := map[string]string{
+		"title": title,
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, "POST", "/api/folders", bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, newApiError(resp)
+	}
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	result := &Folder{}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
@@ -0,0 +1,78 @@
+package gapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// Option configures a Client created by New.
+type Option func(*Client) error
+
+// WithCACert trusts the PEM-encoded CA certificates in file, in addition to
+// the system certificate pool, when verifying the server certificate.
+func WithCACert(file string) Option {
+	return func(c *Client) error {
+		pem, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", file)
+		}
+		c.tlsConfig().RootCAs = pool
+		return nil
+	}
+}
+
+// WithClientCert authenticates to the server with the PEM-encoded client
+// certificate and key in certFile and keyFile.
+func WithClientCert(certFile, keyFile string) Option {
+	return func(c *Client) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		cfg := c.tlsConfig()
+		cfg.Certificates = append(cfg.Certificates, cert)
+		return nil
+	}
+}
+
+// WithServerName verifies the server certificate against name instead of the
+// host in the base URL.
+func WithServerName(name string) Option {
+	return func(c *Client) error {
+		c.tlsConfig().ServerName = name
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify disables verification of the server certificate if
+// insecure is true. This should only be used for testing.
+func WithInsecureSkipVerify(insecure bool) Option {
+	return func(c *Client) error {
+		c.tlsConfig().InsecureSkipVerify = insecure
+		return nil
+	}
+}
+
+// tlsConfig returns the TLS configuration of the client's transport, setting
+// up a transport if the client is using the default.
+func (c *Client) tlsConfig() *tls.Config {
+	t, ok := c.Transport.(*http.Transport)
+	if !ok {
+		t = http.DefaultTransport.(*http.Transport).Clone()
+		c.Transport = t
+	}
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	return t.TLSClientConfig
+}
@@ -2,30 +2,35 @@ package gapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 )
 
 type Org struct {
-	Id   int64
-	Name string
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
 }
 
 func (c *Client) Orgs() ([]Org, error) {
+	return c.OrgsCtx(context.Background())
+}
+
+// OrgsCtx is like Orgs, using ctx for the request.
+func (c *Client) OrgsCtx(ctx context.Context) ([]Org, error) {
 	orgs := make([]Org, 0)
 
-	req, err := c.newRequest("GET", "/api/orgs/", nil)
+	req, err := c.newRequest(ctx, "GET", "/api/orgs/", nil)
 	if err != nil {
 		return orgs, err
 	}
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return orgs, err
 	}
 	if resp.StatusCode != 200 {
-		return orgs, errors.New(resp.Status)
+		return orgs, newApiError(resp)
 	}
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -35,36 +40,58 @@ func (c *Client) Orgs() ([]Org, error) {
 	return orgs, err
 }
 
-func (c *Client) NewOrg(name string) error {
+// NewOrg creates a new organization with name, and returns its id.
+func (c *Client) NewOrg(name string) (int64, error) {
+	return c.NewOrgCtx(context.Background(), name)
+}
+
+// NewOrgCtx is like NewOrg, using ctx for the request.
+func (c *Client) NewOrgCtx(ctx context.Context, name string) (int64, error) {
 	settings := map[string]string{
 		"name": name,
 	}
 	data, err := json.Marshal(settings)
-	req, err := c.newRequest("POST", "/api/orgs", bytes.NewBuffer(data))
 	if err != nil {
-		return err
+		return 0, err
 	}
-	resp, err := c.Do(req)
+	req, err := c.newRequest(ctx, "POST", "/api/orgs", bytes.NewBuffer(data))
 	if err != nil {
-		return err
+		return 0, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, err
 	}
 	if resp.StatusCode != 200 {
-		return errors.New(resp.Status)
+		return 0, newApiError(resp)
 	}
-	return err
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	result := struct {
+		OrgId int64 `json:"orgId"`
+	}{}
+	err = json.Unmarshal(data, &result)
+	return result.OrgId, err
 }
 
 func (c *Client) DeleteOrg(id int64) error {
-	req, err := c.newRequest("DELETE", fmt.Sprintf("/api/orgs/%d", id), nil)
+	return c.DeleteOrgCtx(context.Background(), id)
+}
+
+// DeleteOrgCtx is like DeleteOrg, using ctx for the request.
+func (c *Client) DeleteOrgCtx(ctx context.Context, id int64) error {
+	req, err := c.newRequest(ctx, "DELETE", fmt.Sprintf("/api/orgs/%d", id), nil)
 	if err != nil {
 		return err
 	}
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode != 200 {
-		return errors.New(resp.Status)
+		return newApiError(resp)
 	}
 	return err
 }
@@ -0,0 +1,129 @@
+package gapi
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// RetryPolicy controls how idempotent requests are retried after transient
+// failures: network errors and 429, 502, 503 and 504 responses.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// Requests are not retried if it is less than two.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It is doubled for each
+	// subsequent retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts, if not zero. If the server
+	// asks for a longer delay with Retry-After the request is not retried.
+	MaxDelay time.Duration
+	// Jitter is the fraction (0-1) of each delay that is randomized, to
+	// spread out retries from concurrent requests.
+	Jitter float64
+}
+
+// delay returns how long to wait before retry number n (starting at 1).
+func (p RetryPolicy) delay(n int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n && (p.MaxDelay == 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(p.Jitter * rand.Float64() * float64(d))
+	}
+	return d
+}
+
+// idempotent returns true if requests with method can be safely retried.
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// retryable returns true if resp indicates a transient failure.
+func retryable(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the delay requested by the Retry-After header of resp,
+// if any.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(h); err == nil {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+// do sends req, retrying idempotent requests according to the client's
+// retry policy.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	attempts := c.Retry.MaxAttempts
+	if attempts < 1 || !idempotent(req.Method) {
+		attempts = 1
+	}
+	for n := 1; ; n++ {
+		resp, err := c.Do(req)
+		if n >= attempts || (err == nil && !retryable(resp)) {
+			return resp, err
+		}
+
+		wait := c.Retry.delay(n)
+		ll := log.WithFields(log.Fields{
+			"method":  req.Method,
+			"url":     req.URL.String(),
+			"attempt": n,
+		})
+		if err != nil {
+			ll = ll.WithField("error", err)
+		} else {
+			ll = ll.WithField("status", resp.Status)
+			if ra, ok := retryAfter(resp); ok {
+				// don't wait longer than the policy allows; the caller
+				// gets the response instead
+				if c.Retry.MaxDelay > 0 && ra > c.Retry.MaxDelay {
+					ll.WithField("retry-after", ra).Debug("server asked to wait too long, not retrying")
+					return resp, nil
+				}
+				wait = ra
+			}
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		ll.WithField("wait", wait).Debug("retrying request")
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
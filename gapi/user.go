@@ -1,31 +1,36 @@
 package gapi
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"io/ioutil"
 )
 
 type User struct {
-	Id      int64
-	Email   string
-	Name    string
-	Login   string
-	IsAdmin bool
+	Id      int64  `json:"id"`
+	Email   string `json:"email"`
+	Name    string `json:"name"`
+	Login   string `json:"login"`
+	IsAdmin bool   `json:"isAdmin"`
 }
 
 func (c *Client) Users() ([]User, error) {
+	return c.UsersCtx(context.Background())
+}
+
+// UsersCtx is like Users, using ctx for the request.
+func (c *Client) UsersCtx(ctx context.Context) ([]User, error) {
 	users := make([]User, 0)
-	req, err := c.newRequest("GET", "/api/users", nil)
+	req, err := c.newRequest(ctx, "GET", "/api/users", nil)
 	if err != nil {
 		return users, err
 	}
-	resp, err := c.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return users, err
 	}
 	if resp.StatusCode != 200 {
-		return users, errors.New(resp.Status)
+		return users, newApiError(resp)
 	}
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
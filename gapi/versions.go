@@ -0,0 +1,109 @@
+package gapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// DashboardVersion is a saved version of a dashboard. Data, the dashboard
+// model, is only set by DashboardVersion.
+type DashboardVersion struct {
+	Id            int64                  `json:"id"`
+	DashboardId   int64                  `json:"dashboardId"`
+	ParentVersion int64                  `json:"parentVersion"`
+	RestoredFrom  int64                  `json:"restoredFrom"`
+	Version       int64                  `json:"version"`
+	Created       time.Time              `json:"created"`
+	CreatedBy     string                 `json:"createdBy"`
+	Message       string                 `json:"message"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+}
+
+// DashboardVersions lists the saved versions of the dashboard with id, newest
+// first.
+func (c *Client) DashboardVersions(id int64) ([]DashboardVersion, error) {
+	return c.DashboardVersionsCtx(context.Background(), id)
+}
+
+// DashboardVersionsCtx is like DashboardVersions, using ctx for the request.
+func (c *Client) DashboardVersionsCtx(ctx context.Context, id int64) ([]DashboardVersion, error) {
+	req, err := c.newRequest(ctx, "GET", fmt.Sprintf("/api/dashboards/id/%d/versions", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.DoRead(req)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]DashboardVersion, 0)
+	// newer versions of Grafana wrap the list in an object
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var wrapper struct {
+			Versions []DashboardVersion `json:"versions"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, err
+		}
+		return wrapper.Versions, nil
+	}
+	err = json.Unmarshal(data, &versions)
+	return versions, err
+}
+
+// DashboardVersion fetches version of the dashboard with id, including the
+// dashboard model.
+func (c *Client) DashboardVersion(id, version int64) (*DashboardVersion, error) {
+	return c.DashboardVersionCtx(context.Background(), id, version)
+}
+
+// DashboardVersionCtx is like DashboardVersion, using ctx for the request.
+func (c *Client) DashboardVersionCtx(ctx context.Context, id, version int64) (*DashboardVersion, error) {
+	req, err := c.newRequest(ctx, "GET", fmt.Sprintf("/api/dashboards/id/%d/versions/%d", id, version), nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.DoRead(req)
+	if err != nil {
+		return nil, err
+	}
+	result := &DashboardVersion{}
+	err = json.Unmarshal(data, result)
+	return result, err
+}
+
+// RestoreDashboardVersion restores the dashboard with id to version, saving
+// it as a new version.
+func (c *Client) RestoreDashboardVersion(id, version int64) (*DashboardSaveResponse, error) {
+	return c.RestoreDashboardVersionCtx(context.Background(), id, version)
+}
+
+// RestoreDashboardVersionCtx is like RestoreDashboardVersion, using ctx for
+// the request.
+func (c *Client) RestoreDashboardVersionCtx(ctx context.Context, id, version int64) (*DashboardSaveResponse, error) {
+	data, err := json.Marshal(map[string]int64{"version": version})
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, "POST", fmt.Sprintf("/api/dashboards/id/%d/restore", id), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, apiError(resp, data)
+	}
+	result := &DashboardSaveResponse{}
+	err = json.Unmarshal(data, result)
+	return result, err
+}
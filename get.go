@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/retzkek/grafanactl/gapi"
@@ -15,41 +17,209 @@ var getCmd = &Command{
 	Usage:   "[OPTIONS] [DASHBOARD...]",
 	Summary: "Retrieve dashboards and save to file.",
 	Help: `The get command retrieves dashboards and saves them to file.
-If no dashboards are specified, retrieve all available dashboards.`,
+If no dashboards are specified, retrieve all available dashboards.
+Specify dashboards by UID, or by slug (e.g. 'db/foo' or just 'foo'), which is
+matched to the dashboard's UID in Grafana versions that support UIDs.
+Dashboards specified are saved in files named as given; otherwise files are
+named after the UID, or the slug for Grafana versions that do not support
+UIDs. An existing file named after the slug is updated rather than adding a
+file named after the UID.
+Dashboards are retrieved by -parallel concurrent workers; failures are
+reported in a summary after all dashboards have been attempted.
+
+With '-layout folder', dashboards are saved in a sub-directory of the specified
+path named after the folder they are in, e.g. '<path>/Team/foo.json', instead
+of all in '<path>/db'. Dashboards in the root folder are saved
+in '<path>/General'.
+
+With -all-orgs, retrieve all dashboards from every organization, saving them
+in a sub-directory of the specified path named after the organization, e.g.
+'<path>/Main Org./db/foo.json'.
+
+With '-format yaml', dashboards are saved as YAML instead of JSON, e.g.
+'<path>/db/foo.yaml'. YAML files keep the key order and numbers of the JSON
+model, so they can be pushed back unchanged. A file of the dashboard in the
+other format is removed; dashboards generated from Jsonnet files are not
+overwritten.
+
+With -normalize, dashboards are saved in a canonical form so that getting an
+unchanged dashboard again produces no difference: the fields in -strip are
+removed, and panels (including those in rows) are sorted by position. The id
+and version are kept by default, since they only change when the dashboard is
+saved, and push needs them to update the dashboard and detect conflicts;
+without them push needs -overwrite.
+
+If no dashboards are specified, the dashboards retrieved can be filtered with
+-query, -tag, -starred, -folder and -type (see 'list'), e.g. '-tag team-infra'.`,
 }
 
-func getFunc(client *gapi.Client, cmd *Command, args []string) error {
-	var dashboards []string
-	if len(args) == 0 {
-		dashboards = make([]string, 0)
-		dl, err := client.ListDashboards()
-		if err != nil {
-			log.Error(err)
-			return fmt.Errorf("error getting dashboard list")
-		}
-		for _, d := range *dl {
-			dashboards = append(dashboards, d.URI)
+var (
+	getAllOrgs = getCmd.Flag.Bool("all-orgs", false,
+		"retrieve dashboards from all organizations")
+	getLayout = getCmd.Flag.String("layout", layoutDB,
+		"local repository layout: db, folder")
+	getParallel = getCmd.Flag.Int("parallel", 1,
+		"number of dashboards to retrieve concurrently")
+	getFormat = getCmd.Flag.String("format", formatJSON,
+		"dashboard file format: json, yaml")
+	getNormalize = getCmd.Flag.Bool("normalize", false,
+		"save dashboards in canonical form for clean diffs")
+	getStrip = getCmd.Flag.String("strip", "iteration",
+		"comma-separated fields to remove with -normalize")
+	getSearch = addSearchFlags(&getCmd.Flag)
+)
+
+func getFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	if *getLayout != layoutDB && *getLayout != layoutFolder {
+		return fmt.Errorf("unknown layout %s", *getLayout)
+	}
+	if err := checkFormat(*getFormat, formatJSON, formatYAML); err != nil {
+		return err
+	}
+	if !*getAllOrgs {
+		return getDashboards(ctx, client, *path, args)
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("dashboards cannot be specified with -all-orgs")
+	}
+	orgs, err := client.OrgsCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error getting organization list")
+	}
+	for _, o := range orgs {
+		log.WithField("org", o.Name).Info("retrieving organization dashboards")
+		dir := filepath.Join(*path, safeFilename(o.Name))
+		if err := getDashboards(ctx, client.WithOrg(o.Id), dir, nil); err != nil {
+			return err
 		}
-	} else {
-		dashboards = args
 	}
-	for _, d := range dashboards {
-		dash, err := client.Dashboard(d)
+	return nil
+}
+
+// getDashboards retrieves dashboards and saves them under dir. If names is
+// empty all available dashboards are retrieved.
+func getDashboards(ctx context.Context, client *gapi.Client, dir string, names []string) error {
+	dashboards, index, err := selectDashboards(ctx, client, names, getSearch)
+	if err != nil {
+		return err
+	}
+	results := runParallel(*getParallel, dashboards, func(d string) error {
+		dash, err := index.dashboard(ctx, client, d)
 		if err != nil {
 			log.WithField("dashboard", d).Error(err)
-			return fmt.Errorf("error getting dashboard")
+			return fmt.Errorf("error getting dashboard: %s", err)
+		}
+		dbdir := filepath.Join(dir, "db")
+		if *getLayout == layoutFolder {
+			folder := dash.Meta.FolderTitle
+			if folder == "" {
+				folder = generalFolder
+			}
+			dbdir = filepath.Join(dir, safeFilename(folder))
+		}
+		name := d
+		if len(names) == 0 {
+			name = localName(index, dbdir, d)
+		}
+		filename, err := localFilename(dbdir, name, *getFormat)
+		if err != nil {
+			return err
+		}
+		// files of the dashboard in the other format would be ambiguous
+		stale, err := otherFormats(dbdir, name, filename)
+		if err != nil {
+			return err
+		}
+		if *getNormalize {
+			normalizeDashboard(dash.Model, splitList(*getStrip))
 		}
-		filename := filepath.Join(*path, d) + ".json"
 		log.WithFields(log.Fields{
 			"dashboard": d,
 			"file":      filename,
 		}).Info("saving dashboard")
 		if err := writeDashboard(dash, filename); err != nil {
 			log.WithField("dashboard", d).Error(err)
-			return fmt.Errorf("error saving dashboard to file")
+			return fmt.Errorf("error saving dashboard to file: %s", err)
+		}
+		for _, f := range stale {
+			log.WithField("file", f).Info("removing dashboard file in other format")
+			if err := os.Remove(f); err != nil {
+				log.WithField("file", f).Error(err)
+				return fmt.Errorf("error removing dashboard file: %s", err)
+			}
+		}
+		return nil
+	})
+	return summarize("get", results)
+}
+
+// otherFormats returns the files in dir, other than filename, that hold the
+// dashboard with name in another format, which get replaces. Jsonnet files
+// are sources that get cannot recreate, so they are an error.
+func otherFormats(dir, name, filename string) ([]string, error) {
+	var files []string
+	for _, f := range localFiles(dir, name, "") {
+		switch {
+		case f == filename || filepath.Ext(f) == libraryExtension:
+		case fileFormat(f) == formatJsonnet:
+			return nil, fmt.Errorf("dashboard %s is generated from %s, not overwriting", name, f)
+		default:
+			files = append(files, f)
 		}
 	}
-	return nil
+	return files, nil
+}
+
+// localName returns the name to save the dashboard with name as in dir.
+// Repositories saved before Grafana supported UIDs name dashboards by slug, so
+// an existing file named by the dashboard's slug is kept rather than adding a
+// second file for the same dashboard.
+func localName(index *dashboardIndex, dir, name string) string {
+	if index.scheme != schemeUID || len(localFiles(dir, name, "")) > 0 {
+		return name
+	}
+	s := index.slugOf[name]
+	if s != "" && index.slugs[s] == name && len(localFiles(dir, s, "")) > 0 {
+		return s
+	}
+	return name
+}
+
+// selectDashboards returns the names of the dashboards in Grafana to operate
+// on, and the index used to fetch them. If names is empty all dashboards
+// matching the search filters are selected, otherwise the names are used as
+// given, with any leading "db/" removed; they may be UIDs or slugs.
+func selectDashboards(ctx context.Context, client *gapi.Client, names []string, search *searchFlags) ([]string, *dashboardIndex, error) {
+	index, err := indexDashboards(ctx, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(names) > 0 {
+		dashboards := make([]string, len(names))
+		for i, n := range names {
+			dashboards[i] = strings.TrimPrefix(n, "db/")
+		}
+		return dashboards, index, nil
+	}
+	dl := index.list
+	if search.set() {
+		opts, err := search.options(ctx, client)
+		if err != nil {
+			return nil, nil, err
+		}
+		if dl, err = client.SearchDashboardsCtx(ctx, opts); err != nil {
+			log.Error(err)
+			return nil, nil, fmt.Errorf("error searching dashboards")
+		}
+	}
+	dashboards := make([]string, 0)
+	for _, d := range *dl {
+		if d.Type == "dash-db" {
+			dashboards = append(dashboards, index.scheme.name(d))
+		}
+	}
+	return dashboards, index, nil
 }
 
 func writeDashboard(dash *gapi.Dashboard, filename string) error {
@@ -59,6 +229,9 @@ func writeDashboard(dash *gapi.Dashboard, filename string) error {
 		"where":     "writeDashboard",
 	})
 
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
 	f, err := os.Create(filename)
 	if err != nil {
 		return err
@@ -70,10 +243,16 @@ func writeDashboard(dash *gapi.Dashboard, filename string) error {
 		ll.Error(err)
 		return fmt.Errorf("error marshalling dashboard to JSON")
 	}
+	if fileFormat(filename) == formatYAML {
+		if d, err = jsonToYAML(d); err != nil {
+			ll.Error(err)
+			return fmt.Errorf("error converting dashboard to YAML")
+		}
+	}
 
 	if _, err = f.Write(d); err != nil {
 		ll.Error(err)
-		return fmt.Errorf("error writing JSON to file")
+		return fmt.Errorf("error writing dashboard to file")
 	}
 
 	ll.Debug("successfully wrote file")
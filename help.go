@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -20,7 +21,7 @@ General Usage:
 	grafanactl [OPTIONS] COMMAND [COMMAND OPTIONS]`,
 }
 
-func helpFunc(client *gapi.Client, cmd *Command, args []string) error {
+func helpFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
 	fmt.Printf("grafanactl v%s (%s/%s)\n\n", VERSION, REF, BUILD)
 	switch {
 	case cmd == nil, cmd == helpCmd && len(args) != 1:
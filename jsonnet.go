@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+)
+
+// jsonnetFlags are the options for evaluating Jsonnet dashboard files.
+type jsonnetFlags struct {
+	jpath  stringList
+	extStr stringList
+}
+
+// dashboardJsonnet holds the Jsonnet options of the command being run. The
+// commands that read dashboards share it, since only one runs at a time.
+var dashboardJsonnet = &jsonnetFlags{}
+
+// addFlags adds the Jsonnet flags to fs.
+func (j *jsonnetFlags) addFlags(fs *flag.FlagSet) {
+	fs.Var(&j.jpath, "jpath", "Jsonnet library search path (may be repeated)")
+	fs.Var(&j.extStr, "ext-str", "Jsonnet external variable KEY=VALUE (may be repeated)")
+}
+
+// evaluate evaluates the Jsonnet dashboard file filename, with contents dat,
+// and returns the resulting JSON. Imports are resolved relative to the file,
+// then in the -jpath directories, then in JSONNET_PATH.
+func (j *jsonnetFlags) evaluate(filename string, dat []byte) ([]byte, error) {
+	vm := jsonnet.MakeVM()
+	jpath := append([]string{}, j.jpath...)
+	for _, p := range filepath.SplitList(os.Getenv("JSONNET_PATH")) {
+		if p != "" {
+			jpath = append(jpath, p)
+		}
+	}
+	vm.Importer(&jsonnet.FileImporter{JPaths: jpath})
+	for _, kv := range j.extStr {
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid external variable %q, expected KEY=VALUE", kv)
+		}
+		vm.ExtVar(kv[:i], kv[i+1:])
+	}
+	out, err := vm.EvaluateAnonymousSnippet(filename, string(dat))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+func init() {
+	dashboardJsonnet.addFlags(&pushCmd.Flag)
+	dashboardJsonnet.addFlags(&diffCmd.Flag)
+	dashboardJsonnet.addFlags(&lintCmd.Flag)
+	dashboardJsonnet.addFlags(&renderCmd.Flag)
+	dashboardJsonnet.addFlags(&syncCmd.Flag)
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+var lintCmd = &Command{
+	Name:    "lint",
+	Usage:   "[OPTIONS]",
+	Summary: "Check dashboard files for problems.",
+	Help: `The lint command checks the dashboard files (JSON, YAML or Jsonnet)
+in the specified path for problems before they are pushed, and exits with an
+error if any errors are found. Dashboards are read from the 'db' directory,
+folder directories (see 'get -layout folder') and organization trees (see
+'get -all-orgs'); other files, such as Jsonnet libraries, are not checked.
+The rules are:
+
+	json
+		error: the file is valid JSON, YAML or Jsonnet.
+	required
+		error: the dashboard has a title and panels.
+	panel-ids
+		error: panel IDs are unique.
+	datasources
+		error: datasources referenced are defined in '<path>/datasources'
+		(see 'datasource get'), or with -server in Grafana.
+	duplicate-titles
+		error: dashboard titles are unique within a directory, i.e. a folder.
+	overlap
+		warning: panels do not overlap.
+	empty-queries
+		warning: panel queries are not empty.
+
+All rules are checked unless only some are enabled with -enable, or some are
+disabled with -disable, each a comma-separated list of rules.`,
+}
+
+var (
+	lintFormat = lintCmd.Flag.String("format", "text",
+		"output format: text, json")
+	lintEnable = lintCmd.Flag.String("enable", "",
+		"comma-separated rules to check (default all)")
+	lintDisable = lintCmd.Flag.String("disable", "",
+		"comma-separated rules not to check")
+	lintServer = lintCmd.Flag.Bool("server", false,
+		"also check datasources against those in Grafana")
+)
+
+// Lint rules.
+const (
+	ruleJSON            = "json"
+	ruleRequired        = "required"
+	rulePanelIds        = "panel-ids"
+	ruleDataSources     = "datasources"
+	ruleDuplicateTitles = "duplicate-titles"
+	ruleOverlap         = "overlap"
+	ruleEmptyQueries    = "empty-queries"
+)
+
+// lintRules maps each rule to the severity of its problems.
+var lintRules = map[string]string{
+	ruleJSON:            "error",
+	ruleRequired:        "error",
+	rulePanelIds:        "error",
+	ruleDataSources:     "error",
+	ruleDuplicateTitles: "error",
+	ruleOverlap:         "warning",
+	ruleEmptyQueries:    "warning",
+}
+
+// queryFields are the target fields holding the query for common datasources.
+var queryFields = []string{"expr", "query", "rawSql", "target", "expression", "queryText"}
+
+// lintProblem is a problem found in a dashboard file.
+type lintProblem struct {
+	File     string `json:"file"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// titleKey identifies a dashboard title within a directory, since titles only
+// need to be unique within a folder.
+type titleKey struct {
+	dir, title string
+}
+
+// linter checks dashboards, collecting problems.
+type linter struct {
+	rules    map[string]bool
+	known    map[string]bool
+	titles   map[titleKey]string
+	problems []lintProblem
+}
+
+func (l *linter) report(file, rule, format string, args ...interface{}) {
+	l.problems = append(l.problems, lintProblem{
+		File:     file,
+		Rule:     rule,
+		Severity: lintRules[rule],
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func lintFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	if *lintFormat != "text" && *lintFormat != "json" {
+		return fmt.Errorf("unknown format %s", *lintFormat)
+	}
+	rules, err := selectRules(*lintEnable, *lintDisable)
+	if err != nil {
+		return err
+	}
+	l := &linter{rules: rules, titles: make(map[titleKey]string), problems: make([]lintProblem, 0)}
+	if rules[ruleDataSources] {
+		if l.known, err = lintDataSources(ctx, client); err != nil {
+			return err
+		}
+		if l.known == nil {
+			log.Warning("no datasources found, not checking datasource references")
+		}
+	}
+
+	files, err := lintFiles(*path, true)
+	if err != nil {
+		log.WithField("path", *path).Error(err)
+		return fmt.Errorf("error getting list of dashboards")
+	}
+	for _, f := range files {
+		l.lintFile(f)
+	}
+
+	errors, warnings := 0, 0
+	for _, p := range l.problems {
+		if p.Severity == "error" {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	if *lintFormat == "json" {
+		b, err := json.MarshalIndent(l.problems, "", "\t")
+		if err != nil {
+			log.Error(err)
+			return fmt.Errorf("error marshalling problems to JSON")
+		}
+		fmt.Printf("%s\n", b)
+	} else {
+		for _, p := range l.problems {
+			fmt.Printf("%s: %s: %s (%s)\n", p.File, p.Severity, p.Message, p.Rule)
+		}
+		fmt.Printf("lint: %d file(s), %d error(s), %d warning(s)\n", len(files), errors, warnings)
+	}
+	if errors > 0 {
+		return fmt.Errorf("found %d error(s) in dashboards", errors)
+	}
+	return nil
+}
+
+// selectRules returns the rules to check: those in enable, or all if it is
+// empty, except those in disable.
+func selectRules(enable, disable string) (map[string]bool, error) {
+	rules := make(map[string]bool)
+	if enable == "" {
+		for r := range lintRules {
+			rules[r] = true
+		}
+	}
+	for _, r := range splitList(enable) {
+		if _, ok := lintRules[r]; !ok {
+			return nil, fmt.Errorf("unknown rule %s", r)
+		}
+		rules[r] = true
+	}
+	for _, r := range splitList(disable) {
+		if _, ok := lintRules[r]; !ok {
+			return nil, fmt.Errorf("unknown rule %s", r)
+		}
+		delete(rules, r)
+	}
+	return rules, nil
+}
+
+// lintFiles returns the dashboard files in the repository at root: those in
+// the 'db' directory and in folder directories (see 'get -layout folder'),
+// and, if orgs is set, in organization trees (see 'get -all-orgs') below
+// root.
+func lintFiles(root string, orgs bool) ([]string, error) {
+	files, err := dashboardFiles(filepath.Join(root, "db"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	dirs, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dirs {
+		dir := filepath.Join(root, d.Name())
+		if !d.IsDir() || skipDir(d.Name()) || d.Name() == "db" {
+			continue
+		}
+		var more []string
+		if hasDashboards(dir, "") {
+			more, err = dashboardFiles(dir)
+		} else if orgs {
+			more, err = lintFiles(dir, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, more...)
+	}
+	return files, nil
+}
+
+// dashboardFiles returns the dashboard files, in any format, in dir.
+func dashboardFiles(dir string) ([]string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, fi := range fis {
+		ext := filepath.Ext(fi.Name())
+		if fi.IsDir() || ext == libraryExtension {
+			continue
+		}
+		for _, e := range formatExtensions("") {
+			if ext == e {
+				files = append(files, filepath.Join(dir, fi.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+// lintDataSources returns the names and UIDs of the datasources defined in
+// the local repository and, with -server, in Grafana. If there are none it
+// returns nil.
+func lintDataSources(ctx context.Context, client *gapi.Client) (map[string]bool, error) {
+	known := make(map[string]bool)
+	dir := filepath.Join(*path, dataSourceDir)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		log.WithField("path", dir).Error(err)
+		return nil, fmt.Errorf("error getting list of datasources")
+	}
+	for _, f := range files {
+		if filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		filename := filepath.Join(dir, f.Name())
+		ds, err := readDataSource(filename)
+		if err != nil {
+			log.WithField("file", filename).Error(err)
+			return nil, fmt.Errorf("error loading datasource from file")
+		}
+		known[ds.Name] = true
+		if ds.UID != "" {
+			known[ds.UID] = true
+		}
+	}
+	if *lintServer {
+		for ds := range knownDataSources(ctx, client) {
+			known[ds] = true
+		}
+	}
+	if len(known) == 0 {
+		return nil, nil
+	}
+	return known, nil
+}
+
+// lintFile checks the dashboard file filename.
+func (l *linter) lintFile(filename string) {
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		l.report(filename, ruleJSON, "%s", err)
+		return
+	}
+	model, err := parseDashboard(filename, dat)
+	if err != nil {
+		if l.rules[ruleJSON] {
+			l.report(filename, ruleJSON, "invalid dashboard: %s", err)
+		}
+		return
+	}
+
+	title, _ := model["title"].(string)
+	panels, hasPanels := model["panels"].([]interface{})
+	// dashboards from before Grafana 5.0 have panels in rows
+	rows, hasRows := model["rows"].([]interface{})
+	if l.rules[ruleRequired] {
+		if title == "" {
+			l.report(filename, ruleRequired, "dashboard has no title")
+		}
+		if !hasPanels && !hasRows {
+			l.report(filename, ruleRequired, "dashboard has no panels")
+		}
+	}
+	if l.rules[ruleDuplicateTitles] && title != "" {
+		key := titleKey{filepath.Dir(filename), title}
+		if other, ok := l.titles[key]; ok {
+			l.report(filename, ruleDuplicateTitles, "title %q is also used by %s", title, other)
+		} else {
+			l.titles[key] = filename
+		}
+	}
+	if l.rules[ruleDataSources] && l.known != nil {
+		for _, ds := range unknownDataSources(model, l.known) {
+			l.report(filename, ruleDataSources, "unknown datasource %q", ds)
+		}
+	}
+
+	ids := make(map[float64]string)
+	l.lintPanels(filename, panels, ids)
+	for _, r := range rows {
+		if r, ok := r.(map[string]interface{}); ok {
+			rowPanels, _ := r["panels"].([]interface{})
+			l.lintPanels(filename, rowPanels, ids)
+		}
+	}
+}
+
+// lintPanels checks panels, and the panels of any rows in them, recording
+// panel IDs in ids.
+func (l *linter) lintPanels(filename string, panels []interface{}, ids map[float64]string) {
+	var placed []map[string]interface{}
+	for _, p := range panels {
+		panel, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := panelName(panel)
+		if id, ok := panel["id"].(float64); ok && l.rules[rulePanelIds] {
+			if other, ok := ids[id]; ok {
+				l.report(filename, rulePanelIds, "panel %s has the same id %v as panel %s", name, id, other)
+			} else {
+				ids[id] = name
+			}
+		}
+		if l.rules[ruleOverlap] {
+			for _, other := range placed {
+				if overlaps(panel, other) {
+					l.report(filename, ruleOverlap, "panel %s overlaps panel %s", name, panelName(other))
+				}
+			}
+			if _, ok := panel["gridPos"].(map[string]interface{}); ok {
+				placed = append(placed, panel)
+			}
+		}
+		if l.rules[ruleEmptyQueries] {
+			targets, _ := panel["targets"].([]interface{})
+			for i, t := range targets {
+				if emptyQuery(t) {
+					l.report(filename, ruleEmptyQueries, "panel %s query %d is empty", name, i)
+				}
+			}
+		}
+		if rows, ok := panel["panels"].([]interface{}); ok {
+			l.lintPanels(filename, rows, ids)
+		}
+	}
+}
+
+// panelName returns the title of panel, or its id if it has no title.
+func panelName(panel map[string]interface{}) string {
+	if title, ok := panel["title"].(string); ok && title != "" {
+		return fmt.Sprintf("%q", title)
+	}
+	return fmt.Sprintf("%v", panel["id"])
+}
+
+// overlaps returns true if the gridPos of panels a and b intersect.
+func overlaps(a, b map[string]interface{}) bool {
+	pa, _ := a["gridPos"].(map[string]interface{})
+	pb, _ := b["gridPos"].(map[string]interface{})
+	if pa == nil || pb == nil {
+		return false
+	}
+	num := func(pos map[string]interface{}, k string) float64 {
+		v, _ := pos[k].(float64)
+		return v
+	}
+	return num(pa, "x") < num(pb, "x")+num(pb, "w") &&
+		num(pb, "x") < num(pa, "x")+num(pa, "w") &&
+		num(pa, "y") < num(pb, "y")+num(pb, "h") &&
+		num(pb, "y") < num(pa, "y")+num(pa, "h")
+}
+
+// emptyQuery returns true if target has query fields but all are empty.
+func emptyQuery(target interface{}) bool {
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	found := false
+	for _, f := range queryFields {
+		q, ok := t[f].(string)
+		if !ok {
+			continue
+		}
+		if strings.TrimSpace(q) != "" {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+func init() {
+	lintCmd.Function = lintFunc
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -12,29 +13,46 @@ var listCmd = &Command{
 	Name:    "list",
 	Usage:   "[OPTIONS]",
 	Summary: "List dashboards.",
-	Help:    `The list command lists dashboard names and meta information.`,
+	Help: `The list command lists dashboard names and meta information. Dashboards
+are named by UID, or by slug for Grafana versions that do not support UIDs,
+as for get.
+
+The list can be filtered by title with -query, by tag with -tag, to starred
+dashboards with -starred, by folder title with -folder, and by type (dash-db
+for dashboards or dash-folder for folders) with -type. -tag and -folder may be
+repeated; dashboards must have all the tags, and may be in any of the folders.`,
 }
 
 var (
 	format = listCmd.Flag.String("format", "short",
 		"list format: short, long, json")
+	listSearch = addSearchFlags(&listCmd.Flag)
 )
 
-func listFunc(client *gapi.Client, cmd *Command, args []string) error {
-	dl, err := client.ListDashboards()
+func listFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	dl, scheme, err := listDashboards(ctx, client)
 	if err != nil {
-		log.Error(err)
-		return fmt.Errorf("error getting dashboard list")
+		return err
+	}
+	if listSearch.set() {
+		opts, err := listSearch.options(ctx, client)
+		if err != nil {
+			return err
+		}
+		if dl, err = client.SearchDashboardsCtx(ctx, opts); err != nil {
+			log.Error(err)
+			return fmt.Errorf("error getting dashboard list")
+		}
 	}
 	switch *format {
 	case "short":
 		for _, db := range *dl {
-			fmt.Println(db.URI)
+			fmt.Println(scheme.name(db))
 		}
 	case "long":
-		fmt.Printf("ID     URI                                      TITLE\n")
+		fmt.Printf("ID     NAME                                     TITLE\n")
 		for _, db := range *dl {
-			fmt.Printf("%-6d %-40s %-40s\n", db.Id, db.URI, db.Title)
+			fmt.Printf("%-6d %-40s %-40s\n", db.Id, scheme.name(db), db.Title)
 		}
 	case "json":
 		b, err := json.MarshalIndent(dl, "", "\t")
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Layouts of dashboards in the local repository.
+const (
+	// layoutDB saves all dashboards in one directory, e.g. '<path>/db/foo.json'
+	layoutDB = "db"
+	// layoutFolder saves dashboards by folder, e.g. '<path>/Team/foo.json'
+	layoutFolder = "folder"
+)
+
+// generalFolder is the title of Grafana's root folder.
+const generalFolder = "General"
+
+// dataSourceDir is the sub-directory of the local repository holding
+// datasources, which is never treated as a dashboard folder.
+const dataSourceDir = "datasources"
+
+// Formats of dashboard files in the local repository.
+const (
+	formatJSON    = "json"
+	formatYAML    = "yaml"
+	formatJsonnet = "jsonnet"
+)
+
+// formatExtensions returns the file extensions of dashboard files in format,
+// or of all formats if format is empty. The first is used for new files.
+func formatExtensions(format string) []string {
+	switch format {
+	case formatJSON:
+		return []string{".json"}
+	case formatYAML:
+		return []string{".yaml", ".yml"}
+	case formatJsonnet:
+		return []string{".jsonnet", ".libsonnet"}
+	}
+	return []string{".json", ".yaml", ".yml", ".jsonnet", ".libsonnet"}
+}
+
+// libraryExtension is the extension of Jsonnet libraries, which are only
+// treated as dashboards when named.
+const libraryExtension = ".libsonnet"
+
+// fileFormat returns the format of a dashboard file from its extension.
+func fileFormat(filename string) string {
+	for _, format := range []string{formatYAML, formatJsonnet} {
+		for _, ext := range formatExtensions(format) {
+			if filepath.Ext(filename) == ext {
+				return format
+			}
+		}
+	}
+	return formatJSON
+}
+
+// checkFormat returns an error if format is not one of allowed.
+func checkFormat(format string, allowed ...string) error {
+	for _, f := range allowed {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %s", format)
+}
+
+// localDashboards returns the names (UIDs or slugs) of the dashboards to
+// operate on in dir. If names is empty all dashboards found in dir in format,
+// or any format if format is empty, are returned, otherwise the names are used
+// as given, with any leading "db/" removed.
+func localDashboards(dir string, names []string, format string) ([]string, error) {
+	if len(names) > 0 {
+		dashboards := make([]string, len(names))
+		for i, n := range names {
+			dashboards[i] = strings.TrimPrefix(n, "db/")
+		}
+		return dashboards, nil
+	}
+
+	df, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer df.Close()
+	files, err := df.Readdirnames(0)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool)
+	dashboards := make([]string, 0, len(files))
+	for _, f := range files {
+		for _, ext := range formatExtensions(format) {
+			name := strings.TrimSuffix(f, ext)
+			if filepath.Ext(f) == ext && ext != libraryExtension && !found[name] {
+				found[name] = true
+				dashboards = append(dashboards, name)
+			}
+		}
+	}
+	sort.Strings(dashboards)
+	return dashboards, nil
+}
+
+// skipDir returns true if the directory name never holds dashboards or
+// organizations: hidden directories, e.g. '.git', and the datasources
+// directory.
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == dataSourceDir
+}
+
+// hasDashboards returns true if dir contains dashboard files in format, or
+// any format if format is empty.
+func hasDashboards(dir, format string) bool {
+	dashboards, err := localDashboards(dir, nil, format)
+	return err == nil && len(dashboards) > 0
+}
+
+// isDir returns true if name exists and is a directory.
+func isDir(name string) bool {
+	fi, err := os.Stat(name)
+	return err == nil && fi.IsDir()
+}
+
+// safeFilename returns name with path separators replaced, so that it can be
+// used as a file or directory name.
+func safeFilename(name string) string {
+	return strings.Replace(name, string(filepath.Separator), "_", -1)
+}
+
+// localFilename returns the file in dir that holds the dashboard with name in
+// format, or any format if format is empty. If there is no such file, the
+// name of a new file in format is returned. It is an error if more than one
+// file holds the dashboard, since which is meant is ambiguous.
+func localFilename(dir, name, format string) (string, error) {
+	files := localFiles(dir, name, format)
+	// a Jsonnet library may share its name with the dashboard using it
+	if len(files) > 1 && filepath.Ext(files[len(files)-1]) == libraryExtension {
+		files = files[:len(files)-1]
+	}
+	switch len(files) {
+	case 0:
+		return filepath.Join(dir, name+formatExtensions(format)[0]), nil
+	case 1:
+		return files[0], nil
+	}
+	return "", fmt.Errorf("dashboard %s is in more than one file: %s", name, strings.Join(files, ", "))
+}
+
+// localFiles returns the files in dir that hold the dashboard with name in
+// format, or any format if format is empty.
+func localFiles(dir, name, format string) []string {
+	var files []string
+	for _, ext := range formatExtensions(format) {
+		filename := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(filename); err == nil {
+			files = append(files, filename)
+		}
+	}
+	return files
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"os/signal"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/retzkek/grafanactl/gapi"
@@ -32,13 +35,49 @@ var (
 		"path to local dashboard repository (or set GRAFANA_PATH)")
 	headers = flag.String("headers", "",
 		"Comma-separated list of extra headers to pass, e.g. \"X-User:foo,X-Grafana-Org-Id:1\" (or set GRAFANA_HEADERS)")
+	retries = flag.Int("retries", 3,
+		"number of times to retry idempotent requests after transient errors")
+	retryWait = flag.Duration("retry-wait", time.Second,
+		"wait before first retry, doubled for each subsequent retry")
+	retryMaxWait = flag.Duration("retry-max-wait", 30*time.Second,
+		"maximum wait between retries; longer Retry-After waits are not retried")
+	retryJitter = flag.Float64("retry-jitter", 0.2,
+		"fraction of retry wait to randomize")
+	timeout = flag.Duration("timeout", time.Minute,
+		"timeout for each request, or 0 for no timeout")
+	caCert = flag.String("ca-cert", "",
+		"PEM file of CA certificates to trust (or set GRAFANA_CA_CERT)")
+	clientCert = flag.String("client-cert", "",
+		"PEM file of client certificate for TLS authentication (or set GRAFANA_CLIENT_CERT)")
+	clientKey = flag.String("client-key", "",
+		"PEM file of client key for TLS authentication (or set GRAFANA_CLIENT_KEY)")
+	serverName = flag.String("server-name", "",
+		"server name to verify the Grafana certificate against (or set GRAFANA_SERVER_NAME)")
+	insecure = flag.Bool("insecure", false,
+		"skip verification of the Grafana certificate (or set GRAFANA_INSECURE)")
+	org = flag.Int64("org", 0,
+		"Grafana organization ID (or set GRAFANA_ORG_ID)")
+	configFile = flag.String("config", defaultConfigFile(),
+		"configuration file (or set GRAFANA_CONFIG)")
+	contextName = flag.String("context", "",
+		"context in configuration file to use (or set GRAFANA_CONTEXT)")
 )
 
 var commands = []*Command{
+	configCmd,
+	copyCmd,
+	datasourceCmd,
+	diffCmd,
 	getCmd,
 	helpCmd,
+	lintCmd,
 	listCmd,
+	orgCmd,
 	pushCmd,
+	renderCmd,
+	syncCmd,
+	userCmd,
+	versionsCmd,
 }
 
 func findCommand(cmdName string) *Command {
@@ -56,11 +95,14 @@ func main() {
 
 	// if no command, print help
 	if len(args) == 0 {
-		helpFunc(nil, nil, args)
+		helpFunc(context.Background(), nil, nil, args)
 		os.Exit(1)
 	}
-	// check environment variables
+	// check environment variables, then the configuration file
 	getenv()
+	if err := applyContext(); err != nil {
+		log.Fatal(err)
+	}
 	// setup log
 	if *verbose {
 		log.SetLevel(log.DebugLevel)
@@ -68,19 +110,60 @@ func main() {
 		log.SetLevel(log.InfoLevel)
 	}
 	// setup client
-	client, err := gapi.New(*key, *headers, *url)
+	client, err := newClient(*url, *key, *headers, *org)
 	if err != nil {
 		log.Fatal(err)
 	}
+	// cancel in-flight requests on interrupt; a second interrupt exits
+	// immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 	// find and run command
 	cmdName, args := args[0], args[1:]
 	if cmd := findCommand(cmdName); cmd != nil {
-		cmd.Run(client, args)
+		cmd.Run(ctx, client, args)
 	} else {
 		log.Fatal("Unknown command. 'help' for usage.")
 	}
 }
 
+// newClient creates a Grafana client for the instance at url, configured by
+// the global options.
+func newClient(url, key, headers string, org int64) (*gapi.Client, error) {
+	var opts []gapi.Option
+	if *caCert != "" {
+		opts = append(opts, gapi.WithCACert(*caCert))
+	}
+	if *clientCert != "" || *clientKey != "" {
+		opts = append(opts, gapi.WithClientCert(*clientCert, *clientKey))
+	}
+	if *serverName != "" {
+		opts = append(opts, gapi.WithServerName(*serverName))
+	}
+	if *insecure {
+		opts = append(opts, gapi.WithInsecureSkipVerify(true))
+	}
+	client, err := gapi.New(key, headers, url, opts...)
+	if err != nil {
+		return nil, err
+	}
+	client.Retry = gapi.RetryPolicy{
+		MaxAttempts: *retries + 1,
+		BaseDelay:   *retryWait,
+		MaxDelay:    *retryMaxWait,
+		Jitter:      *retryJitter,
+	}
+	client.Timeout = *timeout
+	if org != 0 {
+		client = client.WithOrg(org)
+	}
+	return client, nil
+}
+
 func getenv() {
 	if *url == DEFAULT_URL {
 		if env := os.Getenv("GRAFANA_URL"); env != "" {
@@ -102,4 +185,44 @@ func getenv() {
 			flag.Set("path", env)
 		}
 	}
+	if *caCert == "" {
+		if env := os.Getenv("GRAFANA_CA_CERT"); env != "" {
+			flag.Set("ca-cert", env)
+		}
+	}
+	if *clientCert == "" {
+		if env := os.Getenv("GRAFANA_CLIENT_CERT"); env != "" {
+			flag.Set("client-cert", env)
+		}
+	}
+	if *clientKey == "" {
+		if env := os.Getenv("GRAFANA_CLIENT_KEY"); env != "" {
+			flag.Set("client-key", env)
+		}
+	}
+	if *serverName == "" {
+		if env := os.Getenv("GRAFANA_SERVER_NAME"); env != "" {
+			flag.Set("server-name", env)
+		}
+	}
+	if !*insecure {
+		if env := os.Getenv("GRAFANA_INSECURE"); env != "" {
+			flag.Set("insecure", env)
+		}
+	}
+	if *org == 0 {
+		if env := os.Getenv("GRAFANA_ORG_ID"); env != "" {
+			flag.Set("org", env)
+		}
+	}
+	if *configFile == defaultConfigFile() {
+		if env := os.Getenv("GRAFANA_CONFIG"); env != "" {
+			flag.Set("config", env)
+		}
+	}
+	if *contextName == "" {
+		if env := os.Getenv("GRAFANA_CONTEXT"); env != "" {
+			flag.Set("context", env)
+		}
+	}
 }
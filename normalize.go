@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// normalizeDashboard puts the dashboard model into a canonical form, so that
+// saving an unchanged dashboard produces an identical file: the fields in
+// strip are removed and panels are sorted by position. Object keys are always
+// sorted when marshalled.
+func normalizeDashboard(model map[string]interface{}, strip []string) {
+	for _, f := range strip {
+		delete(model, f)
+	}
+	sortPanels(model)
+}
+
+// splitList parses a comma-separated list, ignoring empty items.
+func splitList(s string) []string {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// sortPanels sorts the panels of v, and of any rows in it, top to bottom then
+// left to right by gridPos. Panels without a position follow those with one,
+// in their original order.
+func sortPanels(v map[string]interface{}) {
+	for _, k := range []string{"panels", "rows"} {
+		panels, ok := v[k].([]interface{})
+		if !ok {
+			continue
+		}
+		sort.SliceStable(panels, func(i, j int) bool {
+			yi, xi, oki := gridPos(panels[i])
+			yj, xj, okj := gridPos(panels[j])
+			if !oki || !okj {
+				return oki && !okj
+			}
+			if yi != yj {
+				return yi < yj
+			}
+			return xi < xj
+		})
+		for _, p := range panels {
+			if p, ok := p.(map[string]interface{}); ok {
+				sortPanels(p)
+			}
+		}
+	}
+}
+
+// gridPos returns the y and x position of panel, and whether it has one.
+func gridPos(panel interface{}) (y, x float64, ok bool) {
+	p, _ := panel.(map[string]interface{})
+	pos, ok := p["gridPos"].(map[string]interface{})
+	y, _ = pos["y"].(float64)
+	x, _ = pos["x"].(float64)
+	return y, x, ok
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+var orgCmd = &Command{
+	Name:    "org",
+	Usage:   "[OPTIONS] list|create|delete [NAME|ID]",
+	Summary: "Manage organizations.",
+	Help: `The org command manages Grafana organizations.
+
+	list
+		List organizations.
+	create NAME
+		Create a new organization, and print its ID.
+	delete ID
+		Delete the organization with ID.`,
+}
+
+var (
+	orgFormat = orgCmd.Flag.String("format", "short",
+		"list format: short, long, json")
+)
+
+func orgFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing org subcommand: list, create, delete")
+	}
+	switch args[0] {
+	case "list":
+		return listOrgs(ctx, client)
+	case "create":
+		if len(args) != 2 {
+			return fmt.Errorf("org create requires a NAME")
+		}
+		id, err := client.NewOrgCtx(ctx, args[1])
+		if err != nil {
+			log.WithField("org", args[1]).Error(err)
+			return fmt.Errorf("error creating organization")
+		}
+		fmt.Println(id)
+		return nil
+	case "delete":
+		if len(args) != 2 {
+			return fmt.Errorf("org delete requires an ID")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid organization ID %s", args[1])
+		}
+		if err := client.DeleteOrgCtx(ctx, id); err != nil {
+			log.WithField("id", id).Error(err)
+			return fmt.Errorf("error deleting organization")
+		}
+		log.WithField("id", id).Info("organization deleted")
+		return nil
+	}
+	return fmt.Errorf("unknown org subcommand %s", args[0])
+}
+
+func listOrgs(ctx context.Context, client *gapi.Client) error {
+	orgs, err := client.OrgsCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error getting organization list")
+	}
+	switch *orgFormat {
+	case "short":
+		for _, o := range orgs {
+			fmt.Println(o.Name)
+		}
+	case "long":
+		fmt.Printf("ID     NAME\n")
+		for _, o := range orgs {
+			fmt.Printf("%-6d %s\n", o.Id, o.Name)
+		}
+	case "json":
+		b, err := json.MarshalIndent(orgs, "", "\t")
+		if err != nil {
+			log.Error(err)
+			return fmt.Errorf("error marshalling organization list to JSON")
+		}
+		fmt.Printf("%s", b)
+	default:
+		return fmt.Errorf("unknown format %s", *orgFormat)
+	}
+	return nil
+}
+
+func init() {
+	orgCmd.Function = orgFunc
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// result is the outcome of processing a single dashboard.
+type result struct {
+	Name string
+	Err  error
+}
+
+// runParallel calls fn for each of names, using up to n workers, and returns
+// the results in the same order as names.
+func runParallel(n int, names []string, fn func(name string) error) []result {
+	if n < 1 {
+		n = 1
+	}
+	results := make([]result, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = result{Name: names[i], Err: fn(names[i])}
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// summarize prints a summary of results, listing failures in order, and
+// returns an error if any failed.
+func summarize(action string, results []result) error {
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	fmt.Printf("%s: %d succeeded, %d failed\n", action, len(results)-failed, failed)
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("\tFAILED %s: %s\n", r.Name, r.Err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to %s %d of %d dashboard(s)", action, failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+// planAction is the action to take to bring a dashboard in Grafana in line
+// with the local repository.
+type planAction string
+
+const (
+	actionCreate    planAction = "create"
+	actionUpdate    planAction = "update"
+	actionUnchanged planAction = "unchanged"
+	actionDelete    planAction = "delete"
+	actionConflict  planAction = "conflict"
+)
+
+// planEntry describes the action planned for a single dashboard.
+type planEntry struct {
+	Dashboard     string                 `json:"dashboard"`
+	Action        planAction             `json:"action"`
+	Changes       int                    `json:"changes,omitempty"`
+	LocalVersion  float64                `json:"localVersion,omitempty"`
+	RemoteVersion float64                `json:"remoteVersion,omitempty"`
+	Diffs         []difference           `json:"-"`
+	Model         map[string]interface{} `json:"-"`
+	RemoteId      interface{}            `json:"-"`
+}
+
+// makePlan compares the dashboards in dir with those in Grafana and returns
+// the actions needed to push them, and the scheme used to address them. If
+// names is empty all dashboards in dir in format, or any format if format is
+// empty, are considered, rendered with tmpl if not nil. Local dashboards are
+// matched to those in Grafana by UID, or by slug. If prune is set, dashboards
+// that exist in Grafana but match no local dashboard are planned for deletion.
+func makePlan(ctx context.Context, client *gapi.Client, dir string, names []string, format string, tmpl *templateFlags, prune bool) ([]planEntry, dashboardScheme, error) {
+	dashboards, err := localDashboards(dir, names, format)
+	if err != nil {
+		log.WithField("path", dir).Error(err)
+		return nil, schemeSlug, fmt.Errorf("error getting list of dashboards")
+	}
+	index, err := indexDashboards(ctx, client)
+	if err != nil {
+		return nil, schemeSlug, err
+	}
+	scheme := index.scheme
+
+	models := make(map[string]map[string]interface{})
+	for _, d := range dashboards {
+		filename, err := localFilename(dir, d, format)
+		if err != nil {
+			return nil, scheme, err
+		}
+		if models[d], err = readDashboard(filename, tmpl); err != nil {
+			log.WithField("file", filename).Error(err)
+			return nil, scheme, fmt.Errorf("error loading dashboard from file")
+		}
+	}
+	if err := index.checkDuplicates(dashboards, models); err != nil {
+		return nil, scheme, err
+	}
+
+	plan := make([]planEntry, 0, len(dashboards))
+	matched := make(map[string]bool)
+	for _, d := range dashboards {
+		model := models[d]
+		entry := planEntry{Dashboard: d, Action: actionCreate, Model: model}
+		entry.LocalVersion, _ = model["version"].(float64)
+		if remote, ok := index.resolve(d, model); ok {
+			matched[remote] = true
+			if entry.LocalVersion == 0 {
+				log.WithField("dashboard", d).Warning("dashboard has no version, conflicts cannot be detected")
+			}
+			dash, err := scheme.dashboard(ctx, client, remote)
+			if err != nil {
+				log.WithField("dashboard", d).Error(err)
+				return nil, scheme, fmt.Errorf("error getting dashboard")
+			}
+			entry.RemoteId = dash.Model["id"]
+			entry.RemoteVersion, _ = dash.Model["version"].(float64)
+			entry.Diffs = diffDashboards(dash.Model, model)
+			entry.Changes = len(entry.Diffs)
+			switch {
+			case entry.Changes == 0:
+				entry.Action = actionUnchanged
+			case entry.LocalVersion > 0 && entry.RemoteVersion > entry.LocalVersion:
+				// someone changed the dashboard in Grafana since it was saved
+				entry.Action = actionConflict
+			default:
+				entry.Action = actionUpdate
+			}
+		}
+		plan = append(plan, entry)
+	}
+	if prune {
+		var deletes []planEntry
+		for name := range index.names {
+			if !matched[name] {
+				deletes = append(deletes, planEntry{Dashboard: name, Action: actionDelete})
+			}
+		}
+		// if nothing matches, the local dashboards are probably named
+		// differently, and pruning would delete everything
+		if len(matched) == 0 && len(deletes) > 0 {
+			return nil, scheme, fmt.Errorf("no local dashboards match dashboards in Grafana, refusing to prune")
+		}
+		sort.Slice(deletes, func(i, j int) bool {
+			return deletes[i].Dashboard < deletes[j].Dashboard
+		})
+		plan = append(plan, deletes...)
+	}
+	return plan, scheme, nil
+}
+
+// printPlan prints each dashboard that would be changed, with the
+// differences, followed by a summary of plan.
+func printPlan(plan []planEntry) {
+	count := make(map[planAction]int)
+	for _, e := range plan {
+		count[e.Action]++
+		switch e.Action {
+		case actionCreate:
+			fmt.Printf("  + create %s\n", e.Dashboard)
+		case actionUpdate:
+			fmt.Printf("  ~ update %s (%d changes)\n", e.Dashboard, e.Changes)
+		case actionConflict:
+			fmt.Printf("  ! conflict %s (remote version %v is newer than local version %v)\n",
+				e.Dashboard, e.RemoteVersion, e.LocalVersion)
+		case actionDelete:
+			fmt.Printf("  - delete %s\n", e.Dashboard)
+		}
+		for _, d := range e.Diffs {
+			fmt.Printf("\t%s\n", d)
+		}
+	}
+	fmt.Printf("\nPlan: %d to create, %d to update, %d to delete, %d in conflict, %d unchanged.\n",
+		count[actionCreate], count[actionUpdate], count[actionDelete],
+		count[actionConflict], count[actionUnchanged])
+}
+
+// printPlanJSON prints plan as JSON.
+func printPlanJSON(plan []planEntry) error {
+	b, err := json.MarshalIndent(plan, "", "\t")
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error marshalling plan to JSON")
+	}
+	fmt.Printf("%s\n", b)
+	return nil
+}
+
+// planChanges returns true if plan contains any action that would change
+// Grafana.
+func planChanges(plan []planEntry) bool {
+	for _, e := range plan {
+		if e.Action != actionUnchanged {
+			return true
+		}
+	}
+	return false
+}
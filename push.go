@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/retzkek/grafanactl/gapi"
@@ -17,62 +19,268 @@ var pushCmd = &Command{
 	Summary: "Read dashboards from file and push to Grafana.",
 	Help: `The push command reads dashboards from file and pushes them to Grafana.
 If no dashboards are specified, push all dashboards in the specified path.
-Specify dashboards by slug, e.g. 'db/foo' or just 'foo'.
+Specify dashboards by file name, i.e. UID or slug (e.g. 'db/foo' or just 'foo').
+Dashboards are pushed by -parallel concurrent workers; failures are reported
+in a summary after all dashboards have been attempted.
 
 Since only database-stored dashboards can be saved through the Grafana API,
-only dashboards in the 'db' sub-directory are pushed.`,
+only dashboards in the 'db' sub-directory are pushed.
+
+Dashboards may be JSON files, YAML files with extension .yaml or .yml (see
+'get -format yaml'), or Jsonnet files with extension .jsonnet, which are
+evaluated with the library paths given by -jpath and JSONNET_PATH, and the
+external variables given by -ext-str. Jsonnet libraries (.libsonnet) are only
+pushed if named. With -format, only files in that format are pushed.
+
+With '-layout folder', dashboards are read from sub-directories of the specified
+path named after the folder to save them in (see 'get -layout folder'), and
+missing folders are created. Hidden directories, the 'db' and 'datasources'
+directories, and directories without dashboard files are not folders. Specify
+dashboards by folder and slug, e.g. 'Team/foo'.
+
+With -dry-run, nothing is pushed. Instead each dashboard is compared with the
+dashboard in Grafana and the plan is printed, classifying each dashboard as
+create, update, unchanged, or conflict (changed in Grafana since it was saved).
+
+With -all-orgs, push the dashboards in each sub-directory of the specified path
+to the organization of the same name (see 'get -all-orgs'), creating missing
+organizations.
+
+With -datasource-map, datasource references in panels, rows, templating and
+annotations are rewritten before pushing, using a JSON file mapping datasource
+names or UIDs to those in the target Grafana, e.g. {"prod-prometheus":
+"prometheus"}. References that are neither mapped nor present in Grafana are
+reported.
+
+With -template, each dashboard file is rendered as a Go template (see 'help
+render') before it is read, so one file can be pushed with different values,
+e.g. '-var cluster=east'.`,
 }
 
 var (
 	overwrite = pushCmd.Flag.Bool("overwrite", false,
 		"overwrite existing dashboards")
+	dryRun = pushCmd.Flag.Bool("dry-run", false,
+		"print what would be pushed without changing Grafana")
+	planFormat = pushCmd.Flag.String("plan-format", "text",
+		"dry-run plan format: text, json")
+	pushAllOrgs = pushCmd.Flag.Bool("all-orgs", false,
+		"push dashboards to all organizations")
+	pushLayout = pushCmd.Flag.String("layout", layoutDB,
+		"local repository layout: db, folder")
+	pushParallel = pushCmd.Flag.Int("parallel", 1,
+		"number of dashboards to push concurrently")
+	pushDataSourceMap = pushCmd.Flag.String("datasource-map", "",
+		"JSON file mapping datasource names or UIDs to those in Grafana")
+	pushFormat = pushCmd.Flag.String("format", "",
+		"only push dashboard files in format: json, yaml, jsonnet (default any)")
+	pushTemplate = pushCmd.Flag.Bool("template", false,
+		"render dashboard files as templates before pushing")
+	pushVars = addTemplateFlags(&pushCmd.Flag)
 )
 
-func pushFunc(client *gapi.Client, cmd *Command, args []string) error {
-	dirname := filepath.Join(*path, "db")
-	df, err := os.Open(dirname)
-	defer df.Close()
+func pushFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	if *pushLayout != layoutDB && *pushLayout != layoutFolder {
+		return fmt.Errorf("unknown layout %s", *pushLayout)
+	}
+	if err := checkFormat(*pushFormat, "", formatJSON, formatYAML, formatJsonnet); err != nil {
+		return err
+	}
+	if !*pushAllOrgs {
+		return pushTree(ctx, client, *path, args)
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("dashboards cannot be specified with -all-orgs")
+	}
+	orgs, err := client.OrgsCtx(ctx)
 	if err != nil {
-		log.WithField("path", dirname).Error(err)
-		return fmt.Errorf("error opening dashboard directory")
+		log.Error(err)
+		return fmt.Errorf("error getting organization list")
+	}
+	orgIds := make(map[string]int64)
+	for _, o := range orgs {
+		orgIds[safeFilename(o.Name)] = o.Id
+	}
+	files, err := ioutil.ReadDir(*path)
+	if err != nil {
+		log.WithField("path", *path).Error(err)
+		return fmt.Errorf("error getting list of organizations")
+	}
+	for _, f := range files {
+		root := filepath.Join(*path, f.Name())
+		if !f.IsDir() || skipDir(f.Name()) ||
+			(*pushLayout == layoutDB && !isDir(filepath.Join(root, "db"))) {
+			continue
+		}
+		ll := log.WithField("org", f.Name())
+		id, ok := orgIds[f.Name()]
+		if !ok {
+			if *dryRun {
+				ll.Info("organization would be created")
+				continue
+			}
+			id, err = client.NewOrgCtx(ctx, f.Name())
+			if err != nil {
+				ll.Error(err)
+				return fmt.Errorf("error creating organization")
+			}
+			ll.WithField("id", id).Info("organization created")
+		}
+		ll.Info("pushing organization dashboards")
+		if err := pushTree(ctx, client.WithOrg(id), root, nil); err != nil {
+			return err
+		}
 	}
-	// get dashboards from args or directory list
-	var dashboards []string
-	if len(args) == 0 {
-		dashboards, err = df.Readdirnames(0)
+	return nil
+}
+
+// pushTree pushes the dashboards in the local repository at root, according
+// to the layout. If names is empty all dashboards are pushed.
+func pushTree(ctx context.Context, client *gapi.Client, root string, names []string) error {
+	if *pushLayout == layoutDB {
+		return pushDashboards(ctx, client, filepath.Join(root, "db"), names, nil)
+	}
+
+	// find folders, and the dashboards to push in each
+	var titles []string
+	selected := make(map[string][]string)
+	if len(names) == 0 {
+		files, err := ioutil.ReadDir(root)
 		if err != nil {
-			log.WithField("path", dirname).Error(err)
-			return fmt.Errorf("error getting list of dashboards")
+			log.WithField("path", root).Error(err)
+			return fmt.Errorf("error getting list of folders")
+		}
+		// only directories with dashboard files are folders, so that e.g.
+		// organization directories are not created as folders
+		for _, f := range files {
+			if f.IsDir() && !skipDir(f.Name()) && f.Name() != "db" &&
+				hasDashboards(filepath.Join(root, f.Name()), *pushFormat) {
+				titles = append(titles, f.Name())
+			}
 		}
 	} else {
-		dashboards = make([]string, len(args))
-		for i, s := range args {
-			dashboards[i] = s + ".json"
+		for _, n := range names {
+			title, slug := filepath.Split(n)
+			title = strings.TrimSuffix(title, string(filepath.Separator))
+			if title == "" {
+				title = generalFolder
+			}
+			if _, ok := selected[title]; !ok {
+				titles = append(titles, title)
+			}
+			selected[title] = append(selected[title], slug)
+		}
+	}
+
+	folders, err := client.FoldersCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error getting folder list")
+	}
+	byTitle := make(map[string]*gapi.Folder)
+	for i := range folders {
+		byTitle[safeFilename(folders[i].Title)] = &folders[i]
+	}
+	for _, title := range titles {
+		ll := log.WithField("folder", title)
+		var folder *gapi.Folder
+		if title != generalFolder {
+			folder = byTitle[title]
+			if folder == nil && !*dryRun {
+				folder, err = client.NewFolderCtx(ctx, title)
+				if err != nil {
+					ll.Error(err)
+					return fmt.Errorf("error creating folder")
+				}
+				ll.Info("folder created")
+			}
 		}
+		dirname := filepath.Join(root, title)
+		if err := pushDashboards(ctx, client, dirname, selected[title], folder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushDashboards reads dashboards from dir and pushes them to Grafana. If
+// names is empty all dashboards in dir are pushed. With the folder layout
+// dashboards are saved in folder, or the General folder if folder is nil.
+func pushDashboards(ctx context.Context, client *gapi.Client, dirname string, names []string, folder *gapi.Folder) error {
+	if *dryRun {
+		return pushPlan(ctx, client, dirname, names)
+	}
+	dashboards, err := localDashboards(dirname, names, *pushFormat)
+	if err != nil {
+		log.WithField("path", dirname).Error(err)
+		return fmt.Errorf("error getting list of dashboards")
+	}
+	var dsMap map[string]string
+	var known map[string]bool
+	if *pushDataSourceMap != "" {
+		if dsMap, err = readDataSourceMap(*pushDataSourceMap); err != nil {
+			log.WithField("file", *pushDataSourceMap).Error(err)
+			return fmt.Errorf("error reading datasource map")
+		}
+		known = knownDataSources(ctx, client)
+	}
+	index, err := indexDashboards(ctx, client)
+	if err != nil {
+		return err
 	}
-	// read and push dashboards
+	// read all dashboards before pushing any, so that files that are the
+	// same dashboard in Grafana are found first
+	tmpl := templateIf(*pushTemplate, pushVars)
+	filenames := make(map[string]string)
+	models := make(map[string]map[string]interface{})
+	readErrs := make(map[string]error)
 	for _, d := range dashboards {
-		filename := filepath.Join(df.Name(), d)
-		log.WithField("filename", filename).Info("saving dashboard")
-		model, err := readDashboard(filename)
+		filename, err := localFilename(dirname, d, *pushFormat)
+		if err != nil {
+			readErrs[d] = err
+			continue
+		}
+		filenames[d] = filename
+		model, err := readDashboard(filename, tmpl)
 		if err != nil {
 			log.WithField("file", filename).Error(err)
-			return fmt.Errorf("error loading dashboard from file")
+			readErrs[d] = fmt.Errorf("error loading dashboard from file: %s", err)
+			continue
+		}
+		models[d] = model
+	}
+	if err := index.checkDuplicates(dashboards, models); err != nil {
+		return err
+	}
+
+	// push dashboards
+	results := runParallel(*pushParallel, dashboards, func(d string) error {
+		if err := readErrs[d]; err != nil {
+			return err
+		}
+		filename := filenames[d]
+		log.WithField("filename", filename).Info("saving dashboard")
+		model := models[d]
+		if dsMap != nil {
+			remapAndCheck(model, dsMap, known, log.WithField("file", filename))
 		}
-		resp, err := client.SaveDashboard(model, *overwrite)
+		if _, ok := model["version"]; !ok && !*overwrite {
+			log.WithField("file", filename).Warning("dashboard has no version, updating an existing dashboard requires -overwrite")
+		}
+		resp, err := saveDashboard(ctx, client, model, folder)
 		// grafana returns 404 if dashboard we're trying to send includes an id,
 		// but no dashboard exists with that db. Try sending dashboard with nil
 		// id (i.e. create new).
 		if err != nil {
-			if err.Error() == "404 Not Found" {
+			if gapi.IsNotFound(err) {
 				log.Warning("Grafana returned 404. Trying to create new dashboard.")
 				model["id"] = nil
-				resp, err = client.SaveDashboard(model, *overwrite)
+				resp, err = saveDashboard(ctx, client, model, folder)
 			}
 		}
 		if err != nil {
 			log.WithField("file", filename).Error(err)
-			return fmt.Errorf("error pushing dashboard to Grafana")
+			return fmt.Errorf("error pushing dashboard to Grafana: %s", err)
 		}
 		if resp != nil {
 			log.WithFields(log.Fields{
@@ -81,12 +289,40 @@ func pushFunc(client *gapi.Client, cmd *Command, args []string) error {
 				"version": resp.Version,
 			}).Info("dashboard saved")
 		}
+		return nil
+	})
+	return summarize("push", results)
+}
+
+// saveDashboard saves model to Grafana, in folder if using the folder layout.
+func saveDashboard(ctx context.Context, client *gapi.Client, model map[string]interface{}, folder *gapi.Folder) (*gapi.DashboardSaveResponse, error) {
+	if *pushLayout == layoutFolder {
+		return client.SaveDashboardInFolderCtx(ctx, model, folder, *overwrite)
+	}
+	return client.SaveDashboardCtx(ctx, model, *overwrite)
+}
+
+// pushPlan prints what pushing the dashboards in dir would do.
+func pushPlan(ctx context.Context, client *gapi.Client, dir string, names []string) error {
+	plan, _, err := makePlan(ctx, client, dir, names, *pushFormat, templateIf(*pushTemplate, pushVars), false)
+	if err != nil {
+		return err
+	}
+	switch *planFormat {
+	case "text":
+		printPlan(plan)
+	case "json":
+		return printPlanJSON(plan)
+	default:
+		return fmt.Errorf("unknown plan format %s", *planFormat)
 	}
 	return nil
 }
 
-// readDashboard reads a JSON dashboard from file and unmarshals it
-func readDashboard(filename string) (map[string]interface{}, error) {
+// readDashboard reads a JSON, YAML or Jsonnet dashboard, according to the file
+// extension, from file and unmarshals it. If tmpl is not nil the file is
+// rendered as a template first.
+func readDashboard(filename string, tmpl *templateFlags) (map[string]interface{}, error) {
 	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
@@ -96,6 +332,26 @@ func readDashboard(filename string) (map[string]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	if dat, err = tmpl.render(filename, dat); err != nil {
+		return nil, err
+	}
+	return parseDashboard(filename, dat)
+}
+
+// parseDashboard unmarshals the JSON, YAML or Jsonnet dashboard dat, read
+// from filename.
+func parseDashboard(filename string, dat []byte) (map[string]interface{}, error) {
+	var err error
+	switch fileFormat(filename) {
+	case formatYAML:
+		if dat, err = yamlToJSON(dat); err != nil {
+			return nil, err
+		}
+	case formatJsonnet:
+		if dat, err = dashboardJsonnet.evaluate(filename, dat); err != nil {
+			return nil, err
+		}
+	}
 	var v map[string]interface{}
 	if err = json.Unmarshal(dat, &v); err != nil {
 		return nil, err
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+// readDataSourceMap reads a JSON object mapping old datasource names or UIDs
+// to new names or UIDs from filename.
+func readDataSourceMap(filename string) (map[string]string, error) {
+	m := make(map[string]string)
+	if filename == "" {
+		return m, nil
+	}
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(dat, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// walkDataSources walks the dashboard model v, including panels, nested
+// rows, templating and annotations, and calls fn for each datasource
+// reference. A reference is either a name, e.g. "datasource": "prometheus",
+// or an object with a UID, e.g. "datasource": {"type": "prometheus",
+// "uid": "abc123"}. If fn returns a different value the reference is
+// replaced. Variables and built-in datasources are skipped.
+func walkDataSources(v interface{}, fn func(ref string) string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if k != "datasource" {
+				walkDataSources(e, fn)
+				continue
+			}
+			switch ref := e.(type) {
+			case string:
+				if !builtinDataSource(ref) {
+					v[k] = fn(ref)
+				}
+			case map[string]interface{}:
+				uid, ok := ref["uid"].(string)
+				if ok && !builtinDataSource(uid) && ref["type"] != "datasource" {
+					ref["uid"] = fn(uid)
+				}
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			walkDataSources(e, fn)
+		}
+	}
+}
+
+// builtinDataSource returns true if ref is a variable, the default
+// datasource, or one of Grafana's built-in datasources.
+func builtinDataSource(ref string) bool {
+	return ref == "" || ref == "default" || ref == "grafana" ||
+		strings.HasPrefix(ref, "$") || strings.HasPrefix(ref, "-- ")
+}
+
+// remapDataSources replaces the datasource references in the dashboard model
+// v found in m, returning the number of references replaced.
+func remapDataSources(v interface{}, m map[string]string) int {
+	n := 0
+	walkDataSources(v, func(ref string) string {
+		if to, ok := m[ref]; ok {
+			n++
+			return to
+		}
+		return ref
+	})
+	return n
+}
+
+// unknownDataSources returns the datasource references in the dashboard
+// model v that are not in known.
+func unknownDataSources(v interface{}, known map[string]bool) []string {
+	unknown := make(map[string]bool)
+	walkDataSources(v, func(ref string) string {
+		if !known[ref] {
+			unknown[ref] = true
+		}
+		return ref
+	})
+	refs := make([]string, 0, len(unknown))
+	for ref := range unknown {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// knownDataSources returns the names and UIDs of the datasources in Grafana.
+// If they can't be listed, e.g. due to permissions, a warning is logged and
+// nil is returned.
+func knownDataSources(ctx context.Context, client *gapi.Client) map[string]bool {
+	dsl, err := client.DataSourcesCtx(ctx)
+	if err != nil {
+		log.WithField("error", err).Warning("unable to list datasources, not checking references")
+		return nil
+	}
+	known := make(map[string]bool)
+	for _, ds := range dsl {
+		known[ds.Name] = true
+		if ds.UID != "" {
+			known[ds.UID] = true
+		}
+	}
+	return known
+}
+
+// remapAndCheck remaps the datasource references in the dashboard model
+// using m, then logs a warning for any that are not in known. If known is nil
+// references are not checked.
+func remapAndCheck(model map[string]interface{}, m map[string]string, known map[string]bool, ll *log.Entry) {
+	if n := remapDataSources(model, m); n > 0 {
+		ll.WithField("references", n).Debug("remapped datasources")
+	}
+	if known == nil {
+		return
+	}
+	if unknown := unknownDataSources(model, known); len(unknown) > 0 {
+		ll.WithField("datasources", strings.Join(unknown, ", ")).
+			Warning("dashboard references unknown datasources")
+	}
+}
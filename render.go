@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+var renderCmd = &Command{
+	Name:    "render",
+	Usage:   "[OPTIONS] DASHBOARD...",
+	Summary: "Render dashboard templates and print the result.",
+	Help: `The render command renders dashboard files as templates, as 'push
+-template' does, and prints the resolved dashboards without pushing them.
+Specify dashboards by file, or by name as for push. Jsonnet dashboards are
+then evaluated as for push, with -jpath and -ext-str, and printed as JSON.
+
+Dashboard files are Go templates (see https://pkg.go.dev/text/template), with
+variables set from the environment, overridden by the -var-file file of
+KEY=VALUE lines, overridden by -var KEY=VALUE options; e.g. '<%.cluster%>' is
+replaced by the value of cluster. Using a variable that is not set is an
+error.
+
+The template delimiters are '<%' and '%>', not the Go default '{{' and '}}',
+because Grafana uses '{{' in legend formats; change them with -delims.`,
+}
+
+var (
+	renderVars = addTemplateFlags(&renderCmd.Flag)
+)
+
+func renderFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no dashboards given")
+	}
+	for _, d := range args {
+		filename := d
+		if _, err := os.Stat(filename); err != nil {
+			if filename, err = localFilename(filepath.Join(*path, "db"), d, ""); err != nil {
+				return err
+			}
+		}
+		ll := log.WithField("file", filename)
+		dat, err := ioutil.ReadFile(filename)
+		if err != nil {
+			ll.Error(err)
+			return fmt.Errorf("error reading dashboard")
+		}
+		if dat, err = renderVars.render(filename, dat); err != nil {
+			ll.Error(err)
+			return fmt.Errorf("error rendering dashboard")
+		}
+		if fileFormat(filename) == formatJsonnet {
+			if dat, err = dashboardJsonnet.evaluate(filename, dat); err != nil {
+				ll.Error(err)
+				return fmt.Errorf("error evaluating dashboard")
+			}
+		}
+		// check the result is a valid dashboard
+		if _, err := parseDashboard(filename, dat); err != nil {
+			ll.Error(err)
+			return fmt.Errorf("rendered dashboard is invalid")
+		}
+		fmt.Printf("%s\n", dat)
+	}
+	return nil
+}
+
+func init() {
+	renderCmd.Function = renderFunc
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+// dashboardScheme is how dashboards are addressed in Grafana, and so how
+// they are named in the local repository.
+type dashboardScheme int
+
+const (
+	// schemeSlug addresses dashboards by slug, e.g. 'db/foo', and is
+	// supported by Grafana before 8.0.
+	schemeSlug dashboardScheme = iota
+	// schemeUID addresses dashboards by UID, and is supported by Grafana 5.0
+	// and later.
+	schemeUID
+)
+
+func (s dashboardScheme) String() string {
+	if s == schemeUID {
+		return "uid"
+	}
+	return "slug"
+}
+
+// listDashboards gets the list of dashboards from Grafana, and detects the
+// addressing scheme that it supports: if the search results include UIDs
+// dashboards are addressed by UID, otherwise by slug. The list is never
+// filtered, since filtered results may be empty.
+func listDashboards(ctx context.Context, client *gapi.Client) (*gapi.DashboardList, dashboardScheme, error) {
+	dl, err := client.ListDashboardsCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return nil, schemeSlug, fmt.Errorf("error getting dashboard list")
+	}
+	scheme := schemeSlug
+	for _, d := range *dl {
+		if d.UID != "" {
+			scheme = schemeUID
+			break
+		}
+	}
+	log.WithField("scheme", scheme).Debug("detected dashboard addressing scheme")
+	return dl, scheme, nil
+}
+
+// name returns the name of the dashboard in the local repository.
+func (s dashboardScheme) name(d gapi.DashboardEntry) string {
+	if s == schemeUID {
+		return d.UID
+	}
+	return strings.TrimPrefix(d.URI, "db/")
+}
+
+// dashboard fetches the dashboard with name.
+func (s dashboardScheme) dashboard(ctx context.Context, client *gapi.Client, name string) (*gapi.Dashboard, error) {
+	if s == schemeUID {
+		return client.DashboardByUIDCtx(ctx, name)
+	}
+	return client.DashboardCtx(ctx, name)
+}
+
+// deleteDashboard deletes the dashboard with name.
+func (s dashboardScheme) deleteDashboard(ctx context.Context, client *gapi.Client, name string) error {
+	if s == schemeUID {
+		return client.DeleteDashboardByUIDCtx(ctx, name)
+	}
+	return client.DeleteDashboardCtx(ctx, name)
+}
+
+// slug returns the slug of the dashboard, from its URI or, if Grafana no
+// longer reports URIs, from its URL, e.g. '/d/abc123/foo'.
+func slug(d gapi.DashboardEntry) string {
+	if d.URI != "" {
+		return strings.TrimPrefix(d.URI, "db/")
+	}
+	return d.URL[strings.LastIndex(d.URL, "/")+1:]
+}
+
+// dashboardIndex indexes the dashboards in Grafana, so that local dashboards
+// can be matched to them. Local dashboards are named by UID, but repositories
+// saved before Grafana supported UIDs name them by slug.
+type dashboardIndex struct {
+	scheme dashboardScheme
+	list   *gapi.DashboardList
+	names  map[string]bool
+	slugs  map[string]string
+	slugOf map[string]string
+}
+
+// indexDashboards gets the list of dashboards from Grafana and indexes them.
+func indexDashboards(ctx context.Context, client *gapi.Client) (*dashboardIndex, error) {
+	dl, scheme, err := listDashboards(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	x := &dashboardIndex{
+		scheme: scheme,
+		list:   dl,
+		names:  make(map[string]bool),
+		slugs:  make(map[string]string),
+		slugOf: make(map[string]string),
+	}
+	for _, d := range *dl {
+		if d.Type != "dash-db" {
+			continue
+		}
+		x.names[scheme.name(d)] = true
+		x.slugOf[scheme.name(d)] = slug(d)
+		if _, ok := x.slugs[slug(d)]; !ok {
+			x.slugs[slug(d)] = scheme.name(d)
+		}
+	}
+	return x, nil
+}
+
+// resolve returns the name Grafana addresses the local dashboard with name
+// by, and whether it exists in Grafana. If model, the dashboard read from
+// file, is not nil its UID is used in preference to name. Otherwise name is
+// matched as a UID, then as a slug.
+func (x *dashboardIndex) resolve(name string, model map[string]interface{}) (string, bool) {
+	if uid, _ := model["uid"].(string); uid != "" && x.scheme == schemeUID {
+		return uid, x.names[uid]
+	}
+	if x.names[name] {
+		return name, true
+	}
+	if n, ok := x.slugs[name]; ok {
+		return n, true
+	}
+	return name, false
+}
+
+// dashboard fetches the dashboard with name, which may be a UID or a slug.
+func (x *dashboardIndex) dashboard(ctx context.Context, client *gapi.Client, name string) (*gapi.Dashboard, error) {
+	n, _ := x.resolve(name, nil)
+	return x.scheme.dashboard(ctx, client, n)
+}
+
+// checkDuplicates returns an error if two of the local dashboards with names,
+// read from file into models, are the same dashboard in Grafana, e.g. files
+// named by both its slug and its UID, since saving both would overwrite one
+// with the other.
+func (x *dashboardIndex) checkDuplicates(names []string, models map[string]map[string]interface{}) error {
+	seen := make(map[string]string)
+	for _, n := range names {
+		model, ok := models[n]
+		if !ok {
+			continue
+		}
+		remote, _ := x.resolve(n, model)
+		if other, ok := seen[remote]; ok {
+			return fmt.Errorf("%s and %s are both dashboard %s in Grafana", other, n, remote)
+		}
+		seen[remote] = n
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+// stringList is a flag that may be repeated, collecting each value.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// searchFlags are the dashboard search filters of a command.
+type searchFlags struct {
+	query   *string
+	tags    stringList
+	starred *bool
+	folders stringList
+	kind    *string
+}
+
+// addSearchFlags adds the dashboard search filter flags to fs.
+func addSearchFlags(fs *flag.FlagSet) *searchFlags {
+	f := &searchFlags{
+		query: fs.String("query", "",
+			"only dashboards with titles matching query"),
+		starred: fs.Bool("starred", false,
+			"only starred dashboards"),
+		kind: fs.String("type", "",
+			"only results of type: dash-db, dash-folder"),
+	}
+	fs.Var(&f.tags, "tag", "only dashboards with tag (may be repeated)")
+	fs.Var(&f.folders, "folder", "only dashboards in folder with title (may be repeated)")
+	return f
+}
+
+// set returns true if any search filter is set.
+func (f *searchFlags) set() bool {
+	return *f.query != "" || len(f.tags) > 0 || *f.starred ||
+		len(f.folders) > 0 || *f.kind != ""
+}
+
+// options returns the search options for the flags, looking up the IDs of
+// the folders in Grafana.
+func (f *searchFlags) options(ctx context.Context, client *gapi.Client) (gapi.SearchOptions, error) {
+	opts := gapi.SearchOptions{
+		Query:   *f.query,
+		Tags:    f.tags,
+		Starred: *f.starred,
+		Type:    *f.kind,
+	}
+	if len(f.folders) == 0 {
+		return opts, nil
+	}
+	folders, err := client.FoldersCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return opts, fmt.Errorf("error getting folder list")
+	}
+	ids := make(map[string]int64)
+	for _, fo := range folders {
+		ids[fo.Title] = fo.Id
+	}
+	for _, title := range f.folders {
+		id, ok := ids[title]
+		if !ok && title != generalFolder {
+			return opts, fmt.Errorf("folder %s not found", title)
+		}
+		opts.FolderIds = append(opts.FolderIds, id)
+	}
+	return opts, nil
+}
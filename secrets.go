@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+// placeholderRe matches secret placeholders, e.g. ${GRAFANA_DS_PROD_PASSWORD}
+var placeholderRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+var nonAlnumRe = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// secretName returns the name of the variable holding the secret field of
+// the datasource with name, e.g. GRAFANA_DS_PROD_PG_PASSWORD for field
+// "password" of datasource "prod-pg".
+func secretName(dsName, field string) string {
+	name := nonAlnumRe.ReplaceAllString(strings.ToUpper(dsName), "_")
+	field = nonAlnumRe.ReplaceAllString(strings.ToUpper(field), "_")
+	return fmt.Sprintf("GRAFANA_DS_%s_%s", strings.Trim(name, "_"), field)
+}
+
+// redactDataSource replaces secrets in ds with placeholders, so that it can
+// be safely saved to file. Grafana only reports which secure fields are set,
+// so each gets a placeholder in the secure data.
+func redactDataSource(ds *gapi.DataSource) {
+	if ds.Password != "" {
+		ds.Password = "${" + secretName(ds.Name, "password") + "}"
+	}
+	if ds.BasicAuthPassword != "" {
+		ds.BasicAuthPassword = "${" + secretName(ds.Name, "basic_auth_password") + "}"
+	}
+	for field, set := range ds.SecureJSONFields {
+		if set {
+			if ds.SecureJSONData == nil {
+				ds.SecureJSONData = make(map[string]string)
+			}
+			ds.SecureJSONData[field] = ""
+		}
+	}
+	for field := range ds.SecureJSONData {
+		ds.SecureJSONData[field] = "${" + secretName(ds.Name, field) + "}"
+	}
+	ds.SecureJSONFields = nil
+}
+
+// resolveDataSource replaces secret placeholders in ds with their values,
+// looked up first in the environment and then in secrets. An error is
+// returned listing any placeholders that could not be resolved.
+func resolveDataSource(ds *gapi.DataSource, secrets map[string]string) error {
+	missing := make(map[string]bool)
+	resolve := func(s string) string {
+		return placeholderRe.ReplaceAllStringFunc(s, func(p string) string {
+			name := placeholderRe.FindStringSubmatch(p)[1]
+			if v, ok := os.LookupEnv(name); ok {
+				return v
+			}
+			if v, ok := secrets[name]; ok {
+				return v
+			}
+			missing[name] = true
+			return p
+		})
+	}
+	ds.Password = resolve(ds.Password)
+	ds.BasicAuthPassword = resolve(ds.BasicAuthPassword)
+	for field, v := range ds.SecureJSONData {
+		ds.SecureJSONData[field] = resolve(v)
+	}
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for n := range missing {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unresolved secrets for datasource %s: %s",
+			ds.Name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// readSecretsFile reads secrets from a file of KEY=VALUE lines. Blank lines
+// and lines starting with '#' are ignored.
+func readSecretsFile(filename string) (map[string]string, error) {
+	secrets := make(map[string]string)
+	if filename == "" {
+		return secrets, nil
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", filename, n)
+		}
+		secrets[strings.TrimSpace(kv[0])] = kv[1]
+	}
+	return secrets, scanner.Err()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+var syncCmd = &Command{
+	Name:    "sync",
+	Usage:   "[OPTIONS]",
+	Summary: "Make Grafana dashboards match the local repository.",
+	Help: `The sync command makes the local repository the source of truth for
+dashboards in Grafana. New and changed dashboards in the 'db' sub-directory of
+the specified path are pushed, overwriting the dashboards in Grafana, even if
+they were changed in Grafana since they were last saved (a conflict).
+With -prune, dashboards in Grafana that do not exist locally are deleted.
+With -template, dashboard files are rendered as templates as for push, and
+Jsonnet dashboards are evaluated as for push, with -jpath and -ext-str.
+
+The planned changes are printed first, and confirmation is requested before
+applying them unless -yes is given.`,
+}
+
+var (
+	prune = syncCmd.Flag.Bool("prune", false,
+		"delete dashboards from Grafana that do not exist locally")
+	yes = syncCmd.Flag.Bool("yes", false,
+		"apply changes without asking for confirmation")
+	syncTemplate = syncCmd.Flag.Bool("template", false,
+		"render dashboard files as templates (see 'help render')")
+	syncVars = addTemplateFlags(&syncCmd.Flag)
+)
+
+func syncFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	dirname := filepath.Join(*path, "db")
+	plan, scheme, err := makePlan(ctx, client, dirname, nil, "", templateIf(*syncTemplate, syncVars), *prune)
+	if err != nil {
+		return err
+	}
+	printPlan(plan)
+	if !planChanges(plan) {
+		fmt.Println("Nothing to do.")
+		return nil
+	}
+	if !*yes && !confirm("Apply these changes?") {
+		return fmt.Errorf("sync cancelled")
+	}
+
+	for _, e := range plan {
+		ll := log.WithField("dashboard", e.Dashboard)
+		switch e.Action {
+		case actionCreate, actionUpdate, actionConflict:
+			// make sure the id matches the remote dashboard (or is unset for
+			// a new dashboard) so we don't overwrite some other dashboard.
+			e.Model["id"] = e.RemoteId
+			resp, err := client.SaveDashboardCtx(ctx, e.Model, true)
+			if err != nil {
+				ll.Error(err)
+				return fmt.Errorf("error pushing dashboard to Grafana")
+			}
+			ll.WithFields(log.Fields{
+				"status":  resp.Status,
+				"version": resp.Version,
+			}).Info("dashboard saved")
+		case actionDelete:
+			if err := scheme.deleteDashboard(ctx, client, e.Dashboard); err != nil {
+				ll.Error(err)
+				return fmt.Errorf("error deleting dashboard from Grafana")
+			}
+			ll.Info("dashboard deleted")
+		}
+	}
+	return nil
+}
+
+// confirm asks the user a yes/no question on stdin, returning true only if
+// they answer yes.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+func init() {
+	syncCmd.Function = syncFunc
+}
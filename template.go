@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// templateFlags are the options for rendering dashboard files as Go
+// templates.
+type templateFlags struct {
+	vars    stringList
+	varFile *string
+	delims  *string
+
+	once   sync.Once
+	values map[string]string
+	err    error
+}
+
+// addTemplateFlags adds the dashboard template flags to fs.
+func addTemplateFlags(fs *flag.FlagSet) *templateFlags {
+	t := &templateFlags{
+		varFile: fs.String("var-file", "",
+			"file of KEY=VALUE template variables"),
+		delims: fs.String("delims", "<%,%>",
+			"comma-separated left and right template delimiters"),
+	}
+	fs.Var(&t.vars, "var", "template variable KEY=VALUE (may be repeated)")
+	return t
+}
+
+// data returns the template variables: the environment, overridden by the
+// variables file, overridden by the -var options. They are loaded once.
+func (t *templateFlags) data() (map[string]string, error) {
+	t.once.Do(func() {
+		t.values = make(map[string]string)
+		for _, e := range os.Environ() {
+			if i := strings.Index(e, "="); i > 0 {
+				t.values[e[:i]] = e[i+1:]
+			}
+		}
+		file, err := readSecretsFile(*t.varFile)
+		if err != nil {
+			t.err = fmt.Errorf("error reading variables file %s: %s", *t.varFile, err)
+			return
+		}
+		for k, v := range file {
+			t.values[k] = v
+		}
+		for _, kv := range t.vars {
+			i := strings.Index(kv, "=")
+			if i <= 0 {
+				t.err = fmt.Errorf("invalid variable %q, expected KEY=VALUE", kv)
+				return
+			}
+			t.values[kv[:i]] = kv[i+1:]
+		}
+	})
+	return t.values, t.err
+}
+
+// templateIf returns t if enabled is set, otherwise nil, which renders
+// nothing.
+func templateIf(enabled bool, t *templateFlags) *templateFlags {
+	if !enabled {
+		return nil
+	}
+	return t
+}
+
+// render executes the dashboard file filename, with contents dat, as a
+// template. Referencing a variable that is not set is an error. If t is nil
+// dat is returned unchanged.
+func (t *templateFlags) render(filename string, dat []byte) ([]byte, error) {
+	if t == nil {
+		return dat, nil
+	}
+	delims := strings.SplitN(*t.delims, ",", 2)
+	if len(delims) != 2 || delims[0] == "" || delims[1] == "" {
+		return nil, fmt.Errorf("invalid template delimiters %q", *t.delims)
+	}
+	data, err := t.data()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(filepath.Base(filename)).
+		Delims(delims[0], delims[1]).
+		Option("missingkey=error").
+		Parse(string(dat))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
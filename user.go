@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+var userCmd = &Command{
+	Name:    "user",
+	Usage:   "[OPTIONS] list|create|delete [LOGIN...]",
+	Summary: "Manage users.",
+	Help: `The user command manages Grafana users. It requires admin credentials.
+
+	list
+		List users.
+	create
+		Create a user from the -login, -email, -name and -password options,
+		or create users in bulk from -file. The file may be JSON (an array of
+		objects with login, email, name and password fields) or CSV (with a
+		header row naming the same fields). Every user in the file is
+		attempted, and the result for each is reported.
+	delete LOGIN...
+		Delete users by login or email.`,
+}
+
+var (
+	userFormat = userCmd.Flag.String("format", "short",
+		"list format: short, long, json")
+	userLogin = userCmd.Flag.String("login", "",
+		"login of user to create")
+	userEmail = userCmd.Flag.String("email", "",
+		"email of user to create")
+	userName = userCmd.Flag.String("name", "",
+		"name of user to create")
+	userPassword = userCmd.Flag.String("password", "",
+		"password of user to create")
+	userFile = userCmd.Flag.String("file", "",
+		"JSON or CSV file of users to create")
+)
+
+func userFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing user subcommand: list, create, delete")
+	}
+	switch args[0] {
+	case "list":
+		return listUsers(ctx, client)
+	case "create":
+		if *userFile != "" {
+			return createUsersFromFile(ctx, client, *userFile)
+		}
+		form := dtos.AdminCreateUserForm{
+			Login:    *userLogin,
+			Email:    *userEmail,
+			Name:     *userName,
+			Password: *userPassword,
+		}
+		if form.Login == "" && form.Email == "" {
+			return fmt.Errorf("user create requires -login or -email, or -file")
+		}
+		if err := client.CreateUserFormCtx(ctx, form); err != nil {
+			log.WithField("login", form.Login).Error(err)
+			return fmt.Errorf("error creating user")
+		}
+		log.WithField("login", form.Login).Info("user created")
+		return nil
+	case "delete":
+		return deleteUsers(ctx, client, args[1:])
+	}
+	return fmt.Errorf("unknown user subcommand %s", args[0])
+}
+
+func listUsers(ctx context.Context, client *gapi.Client) error {
+	users, err := client.UsersCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error getting user list")
+	}
+	switch *userFormat {
+	case "short":
+		for _, u := range users {
+			fmt.Println(u.Login)
+		}
+	case "long":
+		fmt.Printf("ID     LOGIN                EMAIL                          NAME\n")
+		for _, u := range users {
+			fmt.Printf("%-6d %-20s %-30s %s\n", u.Id, u.Login, u.Email, u.Name)
+		}
+	case "json":
+		b, err := json.MarshalIndent(users, "", "\t")
+		if err != nil {
+			log.Error(err)
+			return fmt.Errorf("error marshalling user list to JSON")
+		}
+		fmt.Printf("%s", b)
+	default:
+		return fmt.Errorf("unknown format %s", *userFormat)
+	}
+	return nil
+}
+
+func createUsersFromFile(ctx context.Context, client *gapi.Client, filename string) error {
+	forms, err := readUserForms(filename)
+	if err != nil {
+		log.WithField("file", filename).Error(err)
+		return fmt.Errorf("error reading users from file")
+	}
+	failed := 0
+	for i, form := range forms {
+		if err := client.CreateUserFormCtx(ctx, form); err != nil {
+			fmt.Printf("%d\t%s\tFAILED\t%s\n", i+1, form.Login, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%d\t%s\tOK\n", i+1, form.Login)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to create %d of %d users", failed, len(forms))
+	}
+	return nil
+}
+
+// readUserForms reads users to create from a JSON or CSV file, depending on
+// the file extension.
+func readUserForms(filename string) ([]dtos.AdminCreateUserForm, error) {
+	var forms []dtos.AdminCreateUserForm
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		dat, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(dat, &forms); err != nil {
+			return nil, err
+		}
+	case ".csv":
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, nil
+		}
+		// map header names to columns
+		cols := make(map[string]int)
+		for i, h := range records[0] {
+			cols[strings.ToLower(strings.TrimSpace(h))] = i
+		}
+		field := func(rec []string, name string) string {
+			if i, ok := cols[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		for _, rec := range records[1:] {
+			forms = append(forms, dtos.AdminCreateUserForm{
+				Login:    field(rec, "login"),
+				Email:    field(rec, "email"),
+				Name:     field(rec, "name"),
+				Password: field(rec, "password"),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown user file type %s", filepath.Ext(filename))
+	}
+	return forms, nil
+}
+
+func deleteUsers(ctx context.Context, client *gapi.Client, logins []string) error {
+	if len(logins) == 0 {
+		return fmt.Errorf("no users specified")
+	}
+	users, err := client.UsersCtx(ctx)
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error getting user list")
+	}
+	for _, login := range logins {
+		id := int64(-1)
+		for _, u := range users {
+			if u.Login == login || u.Email == login {
+				id = u.Id
+				break
+			}
+		}
+		if id < 0 {
+			return fmt.Errorf("user %s not found", login)
+		}
+		if err := client.DeleteUserCtx(ctx, id); err != nil {
+			log.WithField("login", login).Error(err)
+			return fmt.Errorf("error deleting user")
+		}
+		log.WithField("login", login).Info("user deleted")
+	}
+	return nil
+}
+
+func init() {
+	userCmd.Function = userFunc
+}
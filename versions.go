@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/retzkek/grafanactl/gapi"
+)
+
+var versionsCmd = &Command{
+	Name:    "versions",
+	Usage:   "[OPTIONS] [show|restore] DASHBOARD [N]",
+	Summary: "List, show and restore dashboard versions.",
+	Help: `The versions command manages the version history of a dashboard in
+Grafana. Specify the dashboard as for get.
+
+	DASHBOARD
+		List the versions of the dashboard, newest first.
+	show DASHBOARD N
+		Print version N of the dashboard model as JSON.
+	restore DASHBOARD N
+		Restore the dashboard to version N, saving it as a new version.`,
+}
+
+var (
+	versionsFormat = versionsCmd.Flag.String("format", "short",
+		"list format: short, long, json")
+)
+
+func versionsFunc(ctx context.Context, client *gapi.Client, cmd *Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing dashboard")
+	}
+	switch args[0] {
+	case "show", "restore":
+		if len(args) != 3 {
+			return fmt.Errorf("versions %s requires a DASHBOARD and version N", args[0])
+		}
+		version, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %s", args[2])
+		}
+		id, err := dashboardId(ctx, client, args[1])
+		if err != nil {
+			return err
+		}
+		if args[0] == "show" {
+			return showVersion(ctx, client, id, version)
+		}
+		resp, err := client.RestoreDashboardVersionCtx(ctx, id, version)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"dashboard": args[1],
+				"version":   version,
+			}).Error(err)
+			return fmt.Errorf("error restoring dashboard version")
+		}
+		log.WithFields(log.Fields{
+			"dashboard": args[1],
+			"restored":  version,
+			"version":   resp.Version,
+		}).Info("dashboard restored")
+		return nil
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("too many arguments")
+	}
+	id, err := dashboardId(ctx, client, args[0])
+	if err != nil {
+		return err
+	}
+	return listVersions(ctx, client, id)
+}
+
+// dashboardId returns the ID of the dashboard with name, which is needed to
+// address its versions.
+func dashboardId(ctx context.Context, client *gapi.Client, name string) (int64, error) {
+	name = strings.TrimPrefix(name, "db/")
+	index, err := indexDashboards(ctx, client)
+	if err != nil {
+		return 0, err
+	}
+	dash, err := index.dashboard(ctx, client, name)
+	if err != nil {
+		log.WithField("dashboard", name).Error(err)
+		return 0, fmt.Errorf("error getting dashboard")
+	}
+	id, ok := dash.Model["id"].(float64)
+	if !ok {
+		return 0, fmt.Errorf("dashboard %s has no id", name)
+	}
+	return int64(id), nil
+}
+
+func listVersions(ctx context.Context, client *gapi.Client, id int64) error {
+	versions, err := client.DashboardVersionsCtx(ctx, id)
+	if err != nil {
+		log.WithField("id", id).Error(err)
+		return fmt.Errorf("error getting dashboard versions")
+	}
+	switch *versionsFormat {
+	case "short":
+		for _, v := range versions {
+			fmt.Println(v.Version)
+		}
+	case "long":
+		fmt.Printf("VERSION CREATED              AUTHOR               MESSAGE\n")
+		for _, v := range versions {
+			fmt.Printf("%-7d %-20s %-20s %s\n", v.Version,
+				v.Created.Local().Format("2006-01-02 15:04:05"), v.CreatedBy, v.Message)
+		}
+	case "json":
+		b, err := json.MarshalIndent(versions, "", "\t")
+		if err != nil {
+			log.Error(err)
+			return fmt.Errorf("error marshalling version list to JSON")
+		}
+		fmt.Printf("%s", b)
+	default:
+		return fmt.Errorf("unknown format %s", *versionsFormat)
+	}
+	return nil
+}
+
+func showVersion(ctx context.Context, client *gapi.Client, id, version int64) error {
+	v, err := client.DashboardVersionCtx(ctx, id, version)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"id":      id,
+			"version": version,
+		}).Error(err)
+		return fmt.Errorf("error getting dashboard version")
+	}
+	b, err := json.MarshalIndent(v.Data, "", "\t")
+	if err != nil {
+		log.Error(err)
+		return fmt.Errorf("error marshalling dashboard to JSON")
+	}
+	fmt.Printf("%s\n", b)
+	return nil
+}
+
+func init() {
+	versionsCmd.Function = versionsFunc
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonToYAML converts the JSON document dat to YAML, keeping the order of
+// object keys and the literal form of numbers.
+func jsonToYAML(dat []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(dat))
+	dec.UseNumber()
+	node, err := jsonNode(dec)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonNode reads the next JSON value from dec as a YAML node.
+func jsonNode(dec *json.Decoder) (*yaml.Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := jsonNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content,
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k.(string)}, v)
+			}
+			_, err := dec.Token()
+			return node, err
+		}
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for dec.More() {
+			v, err := jsonNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, v)
+		}
+		_, err := dec.Token()
+		return node, err
+	case json.Number:
+		tag := "!!int"
+		if bytes.ContainsAny([]byte(t), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String()}, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(t)}, nil
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+	return nil, fmt.Errorf("unexpected JSON token %v", tok)
+}
+
+// jsonNumberRe matches numbers that are valid JSON literals.
+var jsonNumberRe = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// yamlToJSON converts the YAML document dat to JSON, keeping the order of
+// mapping keys and the literal form of numbers where JSON allows.
+func yamlToJSON(dat []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(dat, &doc); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeJSONNode(&buf, &doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSONNode writes the YAML node as JSON to w.
+func writeJSONNode(w io.Writer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			_, err := io.WriteString(w, "null")
+			return err
+		}
+		return writeJSONNode(w, node.Content[0])
+	case yaml.AliasNode:
+		return writeJSONNode(w, node.Alias)
+	case yaml.MappingNode:
+		io.WriteString(w, "{")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+			k, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			w.Write(k)
+			io.WriteString(w, ":")
+			if err := writeJSONNode(w, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "}")
+		return err
+	case yaml.SequenceNode:
+		io.WriteString(w, "[")
+		for i, n := range node.Content {
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+			if err := writeJSONNode(w, n); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "]")
+		return err
+	}
+	var v interface{}
+	switch node.ShortTag() {
+	case "!!null":
+		v = nil
+	case "!!int", "!!float":
+		if jsonNumberRe.MatchString(node.Value) {
+			_, err := io.WriteString(w, node.Value)
+			return err
+		}
+		if err := node.Decode(&v); err != nil {
+			return err
+		}
+	case "!!bool":
+		if err := node.Decode(&v); err != nil {
+			return err
+		}
+	default:
+		v = node.Value
+	}
+	dat, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(dat)
+	return err
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestYAMLRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{"int", `{"a":1}`},
+		{"negative int", `{"a":-42}`},
+		{"float", `{"a":2.5}`},
+		{"integral float", `{"a":1.0}`},
+		{"trailing zero", `{"a":-2.50}`},
+		{"exponent", `{"a":1e21}`},
+		{"signed exponent", `{"a":1E+21,"b":5e-7}`},
+		{"large int", `{"a":12345678901234567890}`},
+		{"numeric string", `{"a":"0123","b":"1.0","c":"1e3"}`},
+		{"boolean strings", `{"a":"yes","b":"no","c":"true","d":"on"}`},
+		{"null string", `{"a":"null","b":"~"}`},
+		{"empty string", `{"a":""}`},
+		{"null", `{"a":null}`},
+		{"bool", `{"a":true,"b":false}`},
+		{"key order", `{"z":1,"a":{"y":2,"b":3},"m":[{"k":1,"c":2}]}`},
+		{"empty", `{"a":{},"b":[]}`},
+		{"multiline string", `{"a":"line1\nline2\n"}`},
+		{"special characters", `{"a":"- b: c","b":"#x","c":"{{instance}}","d":"${ds}"}`},
+		{"unicode", `{"a":"héllo ✓"}`},
+		{"nested arrays", `{"a":[[1,2],[],["x",null]]}`},
+	}
+	for _, tt := range tests {
+		y, err := jsonToYAML([]byte(tt.json))
+		if err != nil {
+			t.Errorf("%s: jsonToYAML: %s", tt.name, err)
+			continue
+		}
+		j, err := yamlToJSON(y)
+		if err != nil {
+			t.Errorf("%s: yamlToJSON: %s\n%s", tt.name, err, y)
+			continue
+		}
+		if !bytes.Equal(j, []byte(tt.json)) {
+			t.Errorf("%s: got %s, want %s\nYAML:\n%s", tt.name, j, tt.json, y)
+		}
+	}
+}
+
+func TestYAMLToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		json string
+	}{
+		{"plain scalars", "a: 1\nb: 1.5\nc: yes\nd: ~\ne: true\n", `{"a":1,"b":1.5,"c":"yes","d":null,"e":true}`},
+		{"quoted number", "a: \"0123\"\n", `{"a":"0123"}`},
+		{"hex int", "a: 0x1F\n", `{"a":31}`},
+		{"key order", "z: 1\na: 2\n", `{"z":1,"a":2}`},
+		{"alias", "a: &x {b: 1}\nc: *x\n", `{"a":{"b":1},"c":{"b":1}}`},
+	}
+	for _, tt := range tests {
+		j, err := yamlToJSON([]byte(tt.yaml))
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(j, []byte(tt.json)) {
+			t.Errorf("%s: got %s, want %s", tt.name, j, tt.json)
+		}
+		if !json.Valid(j) {
+			t.Errorf("%s: invalid JSON %s", tt.name, j)
+		}
+	}
+}